@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// auditLog returns the audit log at HEROFIG_AUDIT_LOG, or the default audit log if it is not set.
+func auditLog() (*herofig.AuditLog, error) {
+	if path := os.Getenv("HEROFIG_AUDIT_LOG"); path != "" {
+		return &herofig.AuditLog{Path: path}, nil
+	}
+	return herofig.DefaultAuditLog()
+}
+
+// osUser returns the name of the current user of the operating system.
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+func AuditLog(flags *flag.FlagSet) Runner {
+	key := flags.String("key", "", "Only show changes to this key.")
+	since := flags.String("since", "", "Only show changes since this time: a date, an RFC 3339 time, or a duration such as 24h.")
+	until := flags.String("until", "", "Only show changes before this time: a date, an RFC 3339 time, or a duration such as 24h.")
+
+	return func(ctx *Context, args []string) {
+		if len(args) != 0 {
+			ctx.UsageFatal()
+		}
+		app, err := ctx.App()
+		if err != nil {
+			console.Fatalln(err)
+		}
+		filter := herofig.AuditFilter{App: app, Key: *key}
+		now := time.Now()
+		if filter.Since, err = parseTime(*since, now); err != nil {
+			console.Fatalf("invalid --since: %v", err)
+		}
+		if filter.Until, err = parseTime(*until, now); err != nil {
+			console.Fatalf("invalid --until: %v", err)
+		}
+
+		log, err := auditLog()
+		if err != nil {
+			console.Fatalf("opening audit log: %v", err)
+		}
+		entries, err := log.Read(filter)
+		if err != nil {
+			console.Fatalf("reading audit log: %v", err)
+		}
+
+		if ctx.JSON() {
+			printJSON(newAuditOutput(entries))
+			return
+		}
+		if len(entries) == 0 {
+			console.Println(console.Warning("No changes found in %s", log.Path))
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, e := range entries {
+			user := e.User
+			if e.Account != "" {
+				user = fmt.Sprintf("%s (%s)", e.User, e.Account)
+			}
+			keys := make([]string, len(e.Changes))
+			for i, c := range e.Changes {
+				keys[i] = console.ConfigKey(c.Key)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.DateTime), user, console.App(e.App), e.Command, strings.Join(keys, ", "))
+		}
+		tw.Flush()
+	}
+}
+
+// parseTime parses a date, an RFC 3339 time or a duration before now. An empty string is the zero time.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, an RFC 3339 time or a duration", s)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"2024-05-01T08:00:00Z", time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), false},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.s, func(t *testing.T) {
+			got, err := parseTime(c.s, now)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("parseTime(%q) error = %v; want error %v", c.s, err, c.wantErr)
+			}
+			if !got.Equal(c.want) {
+				t.Errorf("parseTime(%q) = %v; want %v", c.s, got, c.want)
+			}
+		})
+	}
+}
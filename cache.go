@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+type cachedList struct {
+	Fetched time.Time `json:"fetched"`
+	Items   []string  `json:"items"`
+}
+
+// cachedStrings returns the list cached under name if it was fetched less than ttl ago, and otherwise calls fetch and
+// caches its result. Errors writing the cache are ignored, since the cache is only an optimization.
+func cachedStrings(name string, ttl time.Duration, fetch func() ([]string, error)) ([]string, error) {
+	dir, err := herofig.CacheDir("lists")
+	if err != nil {
+		return fetch()
+	}
+	path := filepath.Join(dir, name+".json")
+
+	var cached cachedList
+	if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &cached) == nil {
+		if time.Since(cached.Fetched) < ttl {
+			return cached.Items, nil
+		}
+	}
+
+	items, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(cachedList{time.Now(), items}); err == nil {
+		_ = os.WriteFile(path, b, 0600)
+	}
+	return items, nil
+}
+
+// CacheTTL returns the config cache TTL set by HEROFIG_CACHE_TTL, or 0 if caching is disabled.
+func CacheTTL() (time.Duration, error) {
+	v := os.Getenv("HEROFIG_CACHE_TTL")
+	if v == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HEROFIG_CACHE_TTL %q: %v", v, err)
+	}
+	return ttl, nil
+}
+
+// forgetKeys removes the config keys of app cached for completion.
+func forgetKeys(app string) {
+	dir, err := herofig.CacheDir("lists")
+	if err != nil {
+		return
+	}
+	_ = os.Remove(filepath.Join(dir, "keys-"+herofig.CacheName(app)+".json"))
+}
@@ -0,0 +1,626 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// Runner runs a command with its positional arguments, after its flags have been parsed.
+type Runner func(ctx *Context, args []string)
+
+type Command struct {
+	Name    string
+	Args    string
+	Summary string
+	// Aliases are other spellings of the command, made of the name of another command and one other word, such as
+	// "audit log". An alias whose second word is a global flag, such as "watch --remote", is only used if the flag is
+	// not followed by a value, and is ambiguous otherwise.
+	Aliases []string
+	// Setup registers the flags of the command and returns the Runner that runs it.
+	Setup func(flags *flag.FlagSet) Runner
+	// Writes reports whether the command changes the application config. Such commands always read the config from
+	// Heroku rather than the cache, cannot be run offline, and record their changes in the audit log.
+	Writes bool
+	// Uncached commands always read the config from Heroku rather than the cache, and cannot be run offline.
+	Uncached bool
+	// Interruptible commands run until they are interrupted, and stop when the context is canceled on Ctrl-C. Other
+	// commands exit immediately on Ctrl-C, including while asking for confirmation.
+	Interruptible bool
+	// Passthrough stops flag parsing at the first positional argument, so that it and any following arguments can be
+	// passed on to another program.
+	Passthrough bool
+	// Complete returns the shell completion candidates for the positional arguments of the command.
+	Complete func(ctx *Context, toComplete string) []string
+}
+
+// globalFlags are accepted by every command.
+var globalFlags = []struct {
+	name, alias, usage string
+	boolean            bool
+}{
+	{"app", "a", "The Heroku application name.", false},
+	{"env", "e", "The environment in " + herofig.ProjectFilename + " to use.", false},
+	{"remote", "r", "The git remote of the Heroku application, when the application is inferred from git remotes.", false},
+	{"offline", "", "Read the application config from the local cache instead of Heroku.", true},
+	{"json", "", "Print a JSON document instead of human-readable output.", true},
+	{"color", "", "Whether to color output: always, never or auto.", false},
+	{"yes", "y", "Confirm all prompts without asking.", true},
+	{"no-input", "", "Fail instead of asking for confirmation. Also set by HEROFIG_NONINTERACTIVE.", true},
+}
+
+// Context provides commands with the application and environment selected by the global flags. Both are resolved
+// on first use, so that commands which only work with local files do not require a Heroku login. The embedded
+// context.Context of interruptible commands is canceled on interrupt.
+type Context struct {
+	context.Context
+	command *Command
+	flags   *flag.FlagSet
+	app     *string
+	env     *string
+	remote  *string
+	offline *bool
+	json    *bool
+	color   *string
+	yes     *bool
+	noInput *bool
+
+	environment *herofig.Environment
+	backend     herofig.Backend
+}
+
+// Environment returns the selected environment, or the zero Environment if none is selected.
+func (c *Context) Environment() herofig.Environment {
+	env, err := c.resolveEnvironment()
+	if err != nil {
+		console.Fatalln(err)
+	}
+	return env
+}
+
+func (c *Context) resolveEnvironment() (herofig.Environment, error) {
+	if c.environment != nil {
+		return *c.environment, nil
+	}
+
+	name := *c.env
+	if name == "" && !c.AppSelected() {
+		name = os.Getenv("HEROFIG_ENV")
+	}
+
+	var env herofig.Environment
+	if name != "" {
+		project, err := herofig.FindProject(".")
+		if err != nil {
+			return env, err
+		}
+		if project == nil {
+			return env, herofig.Errorf(herofig.ErrNotFound, "Environment %s was selected, but no %s was found", name, herofig.ProjectFilename)
+		}
+		env, err = project.Environment(name)
+		if err != nil {
+			return env, err
+		}
+	}
+	ignoreFile, err := herofig.FindIgnoreFile(".")
+	if err != nil {
+		return env, err
+	}
+	env.IgnoreFile = ignoreFile
+	c.environment = &env
+	return env, nil
+}
+
+// Backend returns the backend of the selected application. For Heroku applications, it makes sure that the user is
+// logged into the Heroku CLI.
+func (c *Context) Backend() herofig.Backend {
+	if c.backend != nil {
+		return c.backend
+	}
+
+	app, err := c.App()
+	if err != nil {
+		console.Fatalln(err)
+	}
+	if app == "" {
+		console.Fail("usage", ExitUsage, "No application specified. Use -a app, -e environment, or run herofig in a directory with a Heroku git remote.")
+	}
+
+	c.backend = c.BackendFor(app)
+	return c.backend
+}
+
+// BackendFor returns the backend of app, for commands that work with more than the selected application.
+func (c *Context) BackendFor(app string) herofig.Backend {
+	b, err := herofig.NewBackend(app)
+	if err != nil {
+		console.Fatalln(err)
+	}
+	if h, ok := b.(*herofig.Heroku); ok {
+		c.setUpHeroku(h)
+	}
+	if c.command.Writes {
+		b = c.audited(b)
+	}
+	return b
+}
+
+func (c *Context) setUpHeroku(h *herofig.Heroku) {
+	cache := h.Cache()
+	cache.Warn = console.Warnf
+	cache.OnInvalidate = forgetKeys
+	cache.Offline = *c.offline
+	switch {
+	case c.command.Writes && cache.Offline:
+		console.Fail("usage", ExitUsage, fmt.Sprintf("%s changes the application config and cannot be run offline.", c.command.Name))
+	case c.command.Uncached && cache.Offline:
+		console.Fail("usage", ExitUsage, fmt.Sprintf("%s cannot be run offline.", c.command.Name))
+	case !c.command.Writes && !c.command.Uncached:
+		ttl, err := CacheTTL()
+		if err != nil {
+			console.Fatalln(err)
+		}
+		cache.TTL = ttl
+	}
+
+	if cache.Offline {
+		return
+	}
+	authenticated, err := h.Authenticated(c)
+	if err != nil {
+		console.Fatalln(err)
+	}
+	if !authenticated {
+		console.Fatalln(herofig.Errorf(herofig.ErrAuth, "You must be logged into the Heroku CLI (heroku login)"))
+	}
+}
+
+// audited returns b, recording the changes made by the command in the audit log.
+func (c *Context) audited(b herofig.Backend) herofig.Backend {
+	log, err := auditLog()
+	if err != nil {
+		console.Fatalf("opening audit log: %v", err)
+	}
+	a := &herofig.AuditedBackend{Backend: b, Log: log, User: osUser(), Command: c.command.Name}
+	if h, ok := b.(*herofig.Heroku); ok {
+		if a.Account, err = h.Account(c); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	return a
+}
+
+// App returns the name of the selected application, or an empty string if none is selected. The application is
+// selected using --app, --env, HEROFIG_APP, HEROFIG_ENV, HEROKU_APP as used by the Heroku CLI, or the Heroku git
+// remotes of the current repository, in that order. HEROKU_APP is ignored when a git remote is selected with
+// --remote. Applications starting with file: are local files used in place of a Heroku application.
+func (c *Context) App() (string, error) {
+	app := *c.app
+	if app == "" && *c.env == "" && *c.remote == "" {
+		app = os.Getenv("HEROFIG_APP")
+	}
+	if app == "" {
+		env, err := c.resolveEnvironment()
+		if err != nil {
+			return "", err
+		}
+		app = env.App
+	}
+	if app == "" && *c.remote == "" {
+		app = os.Getenv("HEROKU_APP")
+	}
+	if app == "" {
+		return herofig.InferApp(".", *c.remote)
+	}
+	return app, nil
+}
+
+// AppSelected reports whether an application was selected using --app, --remote or HEROFIG_APP, rather than
+// inferred from the environment or the git remotes of the current repository.
+func (c *Context) AppSelected() bool {
+	return *c.app != "" || *c.remote != "" || (*c.env == "" && os.Getenv("HEROFIG_APP") != "")
+}
+
+// JSON reports whether the command should print a JSON document instead of human-readable output.
+func (c *Context) JSON() bool {
+	return *c.json
+}
+
+// UsageFatal prints the usage of the running command and exits.
+func (c *Context) UsageFatal() {
+	printCommandUsage(os.Stderr, c.command, c.flags)
+	if c.JSON() {
+		console.Fail("usage", ExitUsage, fmt.Sprintf("invalid arguments to %s", c.command.Name))
+	}
+	os.Exit(ExitUsage)
+}
+
+// Run parses args and runs the selected command.
+func Run(commands []*Command, args []string) {
+	if len(args) > 0 && args[0] == completeCommand {
+		for _, c := range complete(commands, args[1:]) {
+			fmt.Println(c)
+		}
+		return
+	}
+
+	// JSON output is enabled before parsing the flags, so that errors parsing them are printed as JSON.
+	console.SetJSON(jsonFlag(args))
+
+	args, err := resolveAlias(commands, args)
+	if err != nil {
+		console.Fail("usage", ExitUsage, err.Error())
+	}
+	name, rest := splitCommand(args)
+	if name == "" || name == "help" {
+		if name == "help" && len(rest) > 0 {
+			if rest, err = resolveAlias(commands, rest); err != nil {
+				console.Fail("usage", ExitUsage, err.Error())
+			}
+			cmd := findCommand(commands, rest[0])
+			flags := newFlagSet(cmd)
+			cmd.Setup(flags)
+			printCommandUsage(os.Stdout, cmd, flags)
+			return
+		}
+		printUsage(os.Stdout, commands)
+		if name == "" && !slices.Contains(args, "-h") && !slices.Contains(args, "--help") && !slices.Contains(args, "-help") {
+			os.Exit(ExitUsage)
+		}
+		return
+	}
+
+	cmd := findCommand(commands, name)
+	ctx, run := newContext(cmd)
+	if cmd.Interruptible {
+		var stop context.CancelFunc
+		ctx.Context, stop = signal.NotifyContext(ctx.Context, os.Interrupt)
+		defer stop()
+	}
+	flags := ctx.flags
+
+	positional, err := parseArgs(flags, rest, cmd.Passthrough)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandUsage(os.Stdout, cmd, flags)
+		return
+	}
+	if err != nil {
+		console.Fail("usage", ExitUsage, fmt.Sprintf("%v\nRun 'herofig help %s' for usage.", err, cmd.Name))
+	}
+
+	if err := console.SetColor(*ctx.color); err != nil {
+		console.Fail("usage", ExitUsage, err.Error())
+	}
+	console.Prompts = promptMode(*ctx.yes, *ctx.noInput, os.Getenv("HEROFIG_NONINTERACTIVE"))
+
+	run(ctx, positional)
+}
+
+// promptMode returns how to ask for confirmation, given the --yes and --no-input flags and HEROFIG_NONINTERACTIVE.
+// Setting HEROFIG_NONINTERACTIVE to yes confirms prompts, while any other value except 0 and false makes them fail.
+func promptMode(yes, noInput bool, nonInteractive string) console.PromptMode {
+	switch {
+	case yes:
+		return console.PromptYes
+	case noInput:
+		return console.PromptNever
+	}
+	switch strings.ToLower(nonInteractive) {
+	case "", "0", "false":
+		return console.PromptAuto
+	case "yes":
+		return console.PromptYes
+	}
+	return console.PromptNever
+}
+
+// newContext sets up the flags of cmd, including the global flags.
+func newContext(cmd *Command) (*Context, Runner) {
+	flags := newFlagSet(cmd)
+	ctx := &Context{Context: context.Background(), command: cmd, flags: flags}
+	ctx.app = flags.String("app", "", "")
+	ctx.env = flags.String("env", "", "")
+	ctx.remote = flags.String("remote", "", "")
+	ctx.offline = flags.Bool("offline", false, "")
+	ctx.json = flags.Bool("json", false, "")
+	ctx.color = flags.String("color", "auto", "")
+	ctx.yes = flags.Bool("yes", false, "")
+	ctx.noInput = flags.Bool("no-input", false, "")
+	return ctx, cmd.Setup(flags)
+}
+
+func newFlagSet(cmd *Command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func findCommand(commands []*Command, name string) *Command {
+	var names []string
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+		names = append(names, c.Name)
+	}
+
+	msg := fmt.Sprintf("Unknown command %s.", name)
+	if s := suggest(name, names); s != "" {
+		msg += fmt.Sprintf(" Did you mean %s?", s)
+	}
+	console.Fail("usage", ExitUsage, msg+"\nRun 'herofig help' for a list of commands.")
+	return nil
+}
+
+// jsonFlag reports whether --json is among the flags in args.
+func jsonFlag(args []string) bool {
+	for _, a := range args {
+		switch a {
+		case "--":
+			return false
+		case "--json", "-json", "--json=true", "-json=true":
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommand returns the command name in args, which is the first argument that is not a global flag or the value
+// of one, along with args without the command name.
+func splitCommand(args []string) (string, []string) {
+	i := commandIndex(args)
+	if i < 0 {
+		return "", nil
+	}
+	return args[i], append(slices.Clone(args[:i]), args[i+1:]...)
+}
+
+// commandIndex returns the index of the command name in args, which may be preceded by global flags, or -1 if there
+// is none.
+func commandIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			return i
+		}
+		if strings.Contains(a, "=") {
+			continue
+		}
+		name := strings.TrimLeft(a, "-")
+		for _, g := range globalFlags {
+			if !g.boolean && (name == g.name || name == g.alias) {
+				i++
+				break
+			}
+		}
+	}
+	return -1
+}
+
+// resolveAlias replaces the words of a command alias in args with the name of the command. An alias ending with a
+// flag that is followed by a value is an error, since the flag might as well be meant to take that value.
+func resolveAlias(commands []*Command, args []string) ([]string, error) {
+	i := commandIndex(args)
+	if i < 0 || i+1 >= len(args) {
+		return args, nil
+	}
+	for _, c := range commands {
+		for _, alias := range c.Aliases {
+			name, word, _ := strings.Cut(alias, " ")
+			if args[i] != name || args[i+1] != word {
+				continue
+			}
+			if strings.HasPrefix(word, "-") && i+2 < len(args) && !strings.HasPrefix(args[i+2], "-") {
+				return nil, fmt.Errorf("%s %s %s is ambiguous. Use %s instead of %s %s, or %s=%s to pass %s to %s.", name, word, args[i+2], c.Name, name, word, word, args[i+2], args[i+2], word)
+			}
+			return slices.Concat(args[:i], []string{c.Name}, args[i+2:]), nil
+		}
+	}
+	return args, nil
+}
+
+// parseArgs parses the flags in args, which may appear anywhere among the positional arguments, and returns the
+// positional arguments. Arguments following "--" are never parsed as flags.
+func parseArgs(flags *flag.FlagSet, args []string, passthrough bool) ([]string, error) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			if passthrough {
+				positional = append(positional, args[i:]...)
+				break
+			}
+			positional = append(positional, a)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if name == "h" || name == "help" {
+			return nil, flag.ErrHelp
+		}
+		name = globalFlagName(name)
+
+		f := flags.Lookup(name)
+		if f == nil {
+			return nil, unknownFlagError(flags, name)
+		}
+		if isBoolFlag(f) && !hasValue {
+			value, hasValue = "true", true
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if err := flags.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag --%s: %v", value, name, err)
+		}
+	}
+	return positional, nil
+}
+
+// stringsFlag is a flag that may be repeated, collecting all of its values.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func unknownFlagError(flags *flag.FlagSet, name string) error {
+	var names []string
+	flags.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	if s := suggest(name, names); s != "" {
+		return fmt.Errorf("unknown flag --%s. Did you mean --%s?", name, s)
+	}
+	return fmt.Errorf("unknown flag --%s", name)
+}
+
+func printUsage(w io.Writer, commands []*Command) {
+	fmt.Fprintln(w, "Usage: herofig [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Summary)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+	printGlobalFlags(w)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'herofig help <command>' for more information about a command.")
+}
+
+func printCommandUsage(w io.Writer, cmd *Command, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: herofig %s [flags] %s\n", cmd.Name, cmd.Args)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cmd.Summary)
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "Also available as: herofig %s\n", strings.Join(cmd.Aliases, ", herofig "))
+	}
+
+	var commandFlags []*flag.Flag
+	flags.VisitAll(func(f *flag.Flag) {
+		if !isGlobalFlag(f.Name) {
+			commandFlags = append(commandFlags, f)
+		}
+	})
+	if len(commandFlags) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range commandFlags {
+			placeholder, usage := flag.UnquoteUsage(f)
+			if placeholder != "" {
+				placeholder = " " + placeholder
+			}
+			if f.DefValue != "" && f.DefValue != "false" {
+				usage += fmt.Sprintf(" (default %q)", f.DefValue)
+			}
+			fmt.Fprintf(tw, "  --%s%s\t%s\n", f.Name, placeholder, usage)
+		}
+		_ = tw.Flush()
+	}
+	fmt.Fprintln(w)
+	printGlobalFlags(w)
+}
+
+func printGlobalFlags(w io.Writer) {
+	fmt.Fprintln(w, "Global flags:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, g := range globalFlags {
+		name := "    --" + g.name
+		if g.alias != "" {
+			name = fmt.Sprintf("-%s, --%s", g.alias, g.name)
+		}
+		if !g.boolean {
+			name += " " + g.name
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", name, g.usage)
+	}
+	_ = tw.Flush()
+}
+
+// globalFlagName returns the name of the global flag with the alias name, or name itself if it is not an alias.
+func globalFlagName(name string) string {
+	for _, g := range globalFlags {
+		if g.alias != "" && name == g.alias {
+			return g.name
+		}
+	}
+	return name
+}
+
+func isGlobalFlag(name string) bool {
+	for _, g := range globalFlags {
+		if g.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the candidate closest to s, if any is close enough to be a likely misspelling.
+func suggest(s string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, c := range candidates {
+		if strings.HasPrefix(c, s) && len(s) >= 3 {
+			return c
+		}
+		if d := levenshtein(s, c); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
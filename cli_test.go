@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+func TestSplitCommand(t *testing.T) {
+	cases := []struct {
+		args    []string
+		command string
+		rest    []string
+	}{
+		{[]string{"pull"}, "pull", []string{}},
+		{[]string{"-a", "my-app", "pull", "app.env"}, "pull", []string{"-a", "my-app", "app.env"}},
+		{[]string{"--app=my-app", "push", "--mode", "staging"}, "push", []string{"--app=my-app", "--mode", "staging"}},
+		{[]string{"-e", "production"}, "", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.command, func(t *testing.T) {
+			command, rest := splitCommand(c.args)
+			if command != c.command || !slices.Equal(rest, c.rest) {
+				t.Errorf("splitCommand(%q) = %s, %q; want %s, %q", c.args, command, rest, c.command, c.rest)
+			}
+		})
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	commands := []*Command{
+		{Name: "watch"},
+		{Name: "watch:remote", Aliases: []string{"watch --remote"}},
+		{Name: "audit:log", Aliases: []string{"audit log"}},
+	}
+	cases := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"alias", []string{"watch", "--remote"}, []string{"watch:remote"}, false},
+		{"global flags", []string{"-a", "my-app", "watch", "--remote", "--interval", "1m"}, []string{"-a", "my-app", "watch:remote", "--interval", "1m"}, false},
+		{"ambiguous flag", []string{"watch", "--remote", "origin", "dev.env"}, nil, true},
+		{"flag with value", []string{"watch", "--remote=origin", "dev.env"}, []string{"watch", "--remote=origin", "dev.env"}, false},
+		{"command", []string{"watch:remote"}, []string{"watch:remote"}, false},
+		{"word", []string{"--json", "audit", "log", "--since", "24h"}, []string{"--json", "audit:log", "--since", "24h"}, false},
+		{"no alias", []string{"watch", "dev.env"}, []string{"watch", "dev.env"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := resolveAlias(commands, c.args)
+			if (err != nil) != c.wantErr || !slices.Equal(got, c.want) {
+				t.Errorf("resolveAlias(%q) = %q, %v; want %q, error %v", c.args, got, err, c.want, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	app := flags.String("app", "", "")
+	format := flags.String("format", "", "")
+	check := flags.Bool("check", false, "")
+
+	positional, err := parseArgs(flags, []string{"a.env", "-a", "my-app", "--check", "b.env", "--format=json", "--", "--c.env"}, false)
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+
+	want := []string{"a.env", "b.env", "--c.env"}
+	if !slices.Equal(positional, want) {
+		t.Errorf("parseArgs positional = %q; want %q", positional, want)
+	}
+	if *app != "my-app" || *format != "json" || !*check {
+		t.Errorf("parseArgs flags = %s, %s, %v; want my-app, json, true", *app, *format, *check)
+	}
+}
+
+func TestParseArgs_Passthrough(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	app := flags.String("app", "", "")
+	flags.Bool("check", false, "")
+
+	positional, err := parseArgs(flags, []string{"-a", "my-app", "ls", "--check", "-a", "--", "x"}, true)
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+
+	want := []string{"ls", "--check", "-a", "--", "x"}
+	if !slices.Equal(positional, want) {
+		t.Errorf("parseArgs positional = %q; want %q", positional, want)
+	}
+	if *app != "my-app" {
+		t.Errorf("parseArgs app = %s; want my-app", *app)
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown flag", []string{"--fromat", "json"}, "unknown flag --fromat. Did you mean --format?"},
+		{"missing value", []string{"--format"}, "flag --format requires a value"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.String("format", "", "")
+			flags.String("mode", "", "")
+
+			_, err := parseArgs(flags, c.args, false)
+			if err == nil || err.Error() != c.want {
+				t.Errorf("parseArgs(%q) = %v; want %s", c.args, err, c.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"get", "set", "pull", "push", "push:new", "search"}
+	cases := map[string]string{
+		"pul":    "pull",
+		"serach": "search",
+		"psuh":   "push",
+		"xyz":    "",
+	}
+
+	for s, want := range cases {
+		t.Run(s, func(t *testing.T) {
+			if got := suggest(s, candidates); got != want {
+				t.Errorf("suggest(%s) = %s; want %s", s, got, want)
+			}
+		})
+	}
+}
+
+func TestPromptMode(t *testing.T) {
+	cases := []struct {
+		yes            bool
+		noInput        bool
+		nonInteractive string
+		want           console.PromptMode
+	}{
+		{false, false, "", console.PromptAuto},
+		{true, false, "", console.PromptYes},
+		{false, true, "", console.PromptNever},
+		{true, true, "", console.PromptYes},
+		{false, false, "1", console.PromptNever},
+		{false, false, "true", console.PromptNever},
+		{false, false, "0", console.PromptAuto},
+		{false, false, "yes", console.PromptYes},
+		{false, true, "yes", console.PromptNever},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%v %v %q", c.yes, c.noInput, c.nonInteractive), func(t *testing.T) {
+			if got := promptMode(c.yes, c.noInput, c.nonInteractive); got != c.want {
+				t.Errorf("promptMode(%v, %v, %q) = %v; want %v", c.yes, c.noInput, c.nonInteractive, got, c.want)
+			}
+		})
+	}
+}
+
+func TestContext_App(t *testing.T) {
+	dir := t.TempDir()
+	project := `{"environments": {"staging": {"app": "env-app"}}}`
+	if err := os.WriteFile(filepath.Join(dir, herofig.ProjectFilename), []byte(project), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	gitConfig := "[remote \"heroku\"]\n\turl = https://git.heroku.com/git-app.git\n"
+	if err := os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(gitConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	cases := []struct {
+		name    string
+		app     string
+		env     string
+		environ map[string]string
+		want    string
+	}{
+		{"git remote", "", "", nil, "git-app"},
+		{"HEROKU_APP", "", "", map[string]string{"HEROKU_APP": "heroku-app"}, "heroku-app"},
+		{"HEROFIG_APP", "", "", map[string]string{"HEROFIG_APP": "herofig-app", "HEROKU_APP": "heroku-app"}, "herofig-app"},
+		{"HEROFIG_ENV", "", "", map[string]string{"HEROFIG_ENV": "staging", "HEROKU_APP": "heroku-app"}, "env-app"},
+		{"--env", "", "staging", map[string]string{"HEROKU_APP": "heroku-app"}, "env-app"},
+		{"--app", "flag-app", "", map[string]string{"HEROFIG_APP": "herofig-app", "HEROKU_APP": "heroku-app"}, "flag-app"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, k := range []string{"HEROFIG_APP", "HEROFIG_ENV", "HEROKU_APP"} {
+				t.Setenv(k, c.environ[k])
+			}
+			remote := ""
+			ctx := &Context{app: &c.app, env: &c.env, remote: &remote}
+
+			app, err := ctx.App()
+			if err != nil {
+				t.Fatalf("App(): %v", err)
+			}
+			if app != c.want {
+				t.Errorf("App() = %s; want %s", app, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+// completeCommand is the hidden command invoked by the completion scripts, which prints the completion candidates
+// for its arguments.
+const completeCommand = "__complete"
+
+// completionCacheTTL is how long config keys and application names are cached for completion, so that completing
+// does not have to run the Heroku CLI on every keypress.
+const completionCacheTTL = 2 * time.Minute
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for herofig
+_herofig() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}" words=("${COMP_WORDS[@]}") cword=$COMP_CWORD
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(herofig __complete "${words[@]:1:cword}" 2>/dev/null))
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _herofig herofig
+`,
+	"zsh": `#compdef herofig
+# zsh completion for herofig
+_herofig() {
+    local -a candidates
+    candidates=(${(f)"$(herofig __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -S '' -- ${(M)candidates:#*/}
+    compadd -- ${candidates:#*/}
+}
+if [ "$funcstack[1]" = "_herofig" ]; then
+    _herofig "$@"
+else
+    compdef _herofig herofig
+fi
+`,
+	"fish": `# fish completion for herofig
+function __herofig_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    herofig __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c herofig -f -a '(__herofig_complete)'
+`,
+}
+
+func Completion(*flag.FlagSet) Runner {
+	return func(ctx *Context, args []string) {
+		if len(args) != 1 {
+			ctx.UsageFatal()
+		}
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			ctx.UsageFatal()
+		}
+		if ctx.JSON() {
+			printJSON(completionOutput{args[0], script})
+			return
+		}
+		fmt.Print(script)
+	}
+}
+
+// complete returns the completion candidates for the last of words, which are the arguments to herofig up to and
+// including the word being completed.
+func complete(commands []*Command, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	toComplete := words[len(words)-1]
+	// Ambiguous aliases are completed as the command they start with.
+	resolved, err := resolveAlias(commands, words[:len(words)-1])
+	if err != nil {
+		resolved = words[:len(words)-1]
+	}
+	name, rest := splitCommand(resolved)
+
+	cmd := &Command{Setup: func(*flag.FlagSet) Runner { return nil }}
+	for _, c := range commands {
+		if c.Name == name {
+			cmd = c
+		}
+	}
+	ctx, _ := newContext(cmd)
+	if name == "" {
+		rest = words[:len(words)-1]
+	}
+	// Flags are parsed on a best effort basis, to find the application of the config keys to complete.
+	_, _ = parseArgs(ctx.flags, rest, cmd != nil && cmd.Passthrough)
+
+	var candidates []string
+	prefix := ""
+	if f, value, ok := flagValueToComplete(ctx.flags, words); ok {
+		candidates = completeFlagValue(ctx, f, value)
+		if value != toComplete {
+			prefix = strings.TrimSuffix(toComplete, value)
+		}
+		toComplete = value
+	} else if strings.HasPrefix(toComplete, "-") {
+		ctx.flags.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, "--"+f.Name)
+		})
+	} else if name == "" || name == "help" {
+		for _, c := range commands {
+			candidates = append(candidates, c.Name)
+		}
+		if name == "" {
+			candidates = append(candidates, "help")
+		}
+	} else if cmd.Complete != nil {
+		candidates = cmd.Complete(ctx, toComplete)
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			matches = append(matches, prefix+c)
+		}
+	}
+	return matches
+}
+
+// flagValueToComplete returns the flag whose value is being completed, either as --flag=value or as the word
+// following --flag, along with the value completed so far.
+func flagValueToComplete(flags *flag.FlagSet, words []string) (*flag.Flag, string, bool) {
+	toComplete := words[len(words)-1]
+	if name, value, ok := strings.Cut(toComplete, "="); ok && strings.HasPrefix(name, "-") {
+		if f := lookupFlag(flags, name); f != nil {
+			return f, value, true
+		}
+		return nil, "", false
+	}
+
+	if len(words) < 2 || strings.Contains(words[len(words)-2], "=") {
+		return nil, "", false
+	}
+	f := lookupFlag(flags, words[len(words)-2])
+	if f == nil || isBoolFlag(f) {
+		return nil, "", false
+	}
+	return f, toComplete, true
+}
+
+func lookupFlag(flags *flag.FlagSet, arg string) *flag.Flag {
+	if !strings.HasPrefix(arg, "-") {
+		return nil
+	}
+	return flags.Lookup(globalFlagName(strings.TrimLeft(arg, "-")))
+}
+
+func completeFlagValue(ctx *Context, f *flag.Flag, toComplete string) []string {
+	switch f.Name {
+	case "app":
+		return completeApps(ctx)
+	case "env":
+		return completeEnvironments()
+	case "color":
+		return []string{"always", "auto", "never"}
+	case "format":
+		names := make([]string, len(herofig.Formats))
+		for i, format := range herofig.Formats {
+			names[i] = format.Name
+		}
+		return names
+	case "template-file":
+		return completeFiles(toComplete, func(string) bool { return true })
+	}
+	return nil
+}
+
+// completeKeys completes the config keys of the selected application.
+func completeKeys(ctx *Context, _ string) []string {
+	app, err := ctx.App()
+	if err != nil || app == "" {
+		return nil
+	}
+	// Only the keys are cached, never the values.
+	keys, _ := cachedStrings("keys-"+herofig.CacheName(app), completionCacheTTL, func() ([]string, error) {
+		b, err := herofig.NewBackend(app)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := b.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Sorted(maps.Keys(cfg)), nil
+	})
+	return keys
+}
+
+// completeConfigFiles completes the names of directories and files in the formats herofig can read or write.
+func completeConfigFiles(_ *Context, toComplete string) []string {
+	return completeFiles(toComplete, isConfigFile)
+}
+
+func completeShells(*Context, string) []string {
+	return slices.Sorted(maps.Keys(completionScripts))
+}
+
+func completeApps(ctx *Context) []string {
+	apps, _ := cachedStrings("apps", completionCacheTTL, func() ([]string, error) {
+		return herofig.NewHeroku("").Apps(ctx)
+	})
+	return apps
+}
+
+func completeEnvironments() []string {
+	project, err := herofig.FindProject(".")
+	if err != nil || project == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(project.Environments))
+}
+
+// completeFiles completes the names of directories and the files accepted by match. Hidden files are only completed
+// if they are accepted by match or toComplete starts with a dot.
+func completeFiles(toComplete string, match func(name string) bool) []string {
+	dir, base := filepath.Split(toComplete)
+	entries, err := os.ReadDir(filepath.Join(".", dir))
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if e.IsDir() {
+			if !strings.HasPrefix(name, ".") || strings.HasPrefix(base, ".") {
+				files = append(files, dir+name+"/")
+			}
+			continue
+		}
+		if match(name) || (strings.HasPrefix(base, ".") && strings.HasPrefix(name, ".")) {
+			files = append(files, dir+name)
+		}
+	}
+	return files
+}
+
+// isConfigFile reports whether name looks like a file in one of the supported formats.
+func isConfigFile(name string) bool {
+	if strings.HasPrefix(name, ".env") || strings.HasSuffix(name, ".env") {
+		return true
+	}
+	for _, f := range herofig.Formats {
+		if slices.Contains(f.Filenames, name) {
+			return true
+		}
+		for _, e := range f.Extensions {
+			if strings.EqualFold(filepath.Ext(name), e) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".env", "staging.env", "app.json", "notes.txt", "config/production.env"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	cases := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"pu"}, []string{"pull", "push", "push:new"}},
+		{[]string{"-a", "my-app", "re"}, []string{"render", "releases"}},
+		{[]string{"help", "h"}, []string{"hash"}},
+		{[]string{"push", ""}, []string{".env", "app.json", "config/", "staging.env"}},
+		{[]string{"push", "config/"}, []string{"config/production.env"}},
+		{[]string{"fmt", "--ch"}, []string{"--check"}},
+		{[]string{"--color", ""}, []string{"always", "auto", "never"}},
+		{[]string{"pull", "--format", "y"}, []string{"yaml"}},
+		{[]string{"pull", "--format=to"}, []string{"--format=toml"}},
+		{[]string{"fmt", "--check", "s"}, []string{"staging.env"}},
+		{[]string{"completion", ""}, []string{"bash", "fish", "zsh"}},
+	}
+
+	for _, c := range cases {
+		t.Run(strings.Join(c.words, " "), func(t *testing.T) {
+			got := complete(Commands, c.words)
+			if !slices.Equal(got, c.want) {
+				t.Errorf("complete(%q) = %q; want %q", c.words, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// driftTarget is a set of local files that should match the config of an application.
+type driftTarget struct {
+	env   herofig.Environment
+	app   string
+	files []string
+}
+
+func Drift(flags *flag.FlagSet) Runner {
+	sources := addSourceFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		targets, err := driftTargets(ctx, args)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		if len(targets) == 0 {
+			console.Fatalln(herofig.Errorf(herofig.ErrNotFound, "No environments with an app and files found in %s. Pass files=app arguments to compare files with apps.", herofig.ProjectFilename))
+		}
+
+		out := driftOutput{Targets: make([]driftReport, 0, len(targets))}
+		for _, t := range targets {
+			local, files := sources.load(t.files, t.env)
+			remote, err := ctx.BackendFor(t.app).Config(ctx)
+			if err != nil {
+				console.Fatalf("getting config from %s: %v", t.app, err)
+			}
+			report := newDriftReport(t.env.Name, t.app, files, herofig.Diff(local, t.env.Filter(remote)))
+			out.Targets = append(out.Targets, report)
+			out.Drifted = out.Drifted || report.Drifted
+		}
+
+		if ctx.JSON() {
+			printJSON(out)
+		} else {
+			printDrift(out)
+		}
+		if out.Drifted {
+			os.Exit(ExitDrift)
+		}
+	}
+}
+
+// driftTargets returns the targets given as files=app arguments, where files are separated by commas. Without
+// arguments, the selected environment is used, or else every environment of the project with an app and files,
+// limited to the environments of the selected application if there is one.
+func driftTargets(ctx *Context, args []string) ([]driftTarget, error) {
+	env := ctx.Environment()
+	if len(args) > 0 {
+		targets := make([]driftTarget, len(args))
+		for i, a := range args {
+			files, app, ok := strings.Cut(a, "=")
+			if !ok || files == "" || app == "" {
+				return nil, herofig.Errorf(herofig.ErrValidation, "invalid argument %q (must be files=app)", a)
+			}
+			targets[i] = driftTarget{env, app, strings.Split(files, ",")}
+		}
+		return targets, nil
+	}
+	var app string
+	if env.Name != "" || ctx.AppSelected() {
+		var err error
+		if app, err = ctx.App(); err != nil {
+			return nil, err
+		}
+	}
+	if env.Name != "" {
+		return []driftTarget{{env, app, env.Files}}, nil
+	}
+
+	project, err := herofig.FindProject(".")
+	if err != nil {
+		return nil, err
+	}
+	var targets []driftTarget
+	if project != nil {
+		for _, name := range slices.Sorted(maps.Keys(project.Environments)) {
+			e := project.Environments[name]
+			e.IgnoreFile = env.IgnoreFile
+			if e.App != "" && len(e.Files) > 0 && (app == "" || herofig.SameApp(e.App, app)) {
+				targets = append(targets, driftTarget{e, e.App, e.Files})
+			}
+		}
+	}
+	if len(targets) == 0 && app != "" {
+		return nil, herofig.Errorf(herofig.ErrNotFound, "No environments of %s with files found in %s. Pass files=%s arguments to compare files with %s.", app, herofig.ProjectFilename, app, app)
+	}
+	return targets, nil
+}
+
+func printDrift(out driftOutput) {
+	for _, r := range out.Targets {
+		labels := make([]string, len(r.Files))
+		for i, f := range r.Files {
+			labels[i] = console.FilePath(f)
+		}
+		name := console.App(r.App)
+		if r.Environment != "" {
+			name = fmt.Sprintf("%s (%s)", r.Environment, console.App(r.App))
+		}
+		if !r.Drifted {
+			fmt.Printf("%s: %s %s\n", name, strings.Join(labels, " + "), console.Success("in sync"))
+			continue
+		}
+		fmt.Printf("%s: %s %s\n", name, strings.Join(labels, " + "), console.Error("%d %s drifted", len(r.Keys), pluralize("key", "", "s", len(r.Keys))))
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, k := range r.Keys {
+			switch k.Drift {
+			case driftAddedOnApp:
+				fmt.Fprintf(tw, "  + %s\tadded on app\tapp %s\n", console.ConfigKey(k.Key), k.App)
+			case driftMissingOnApp:
+				fmt.Fprintf(tw, "  - %s\tmissing on app\tfile %s\n", console.ConfigKey(k.Key), k.File)
+			default:
+				fmt.Fprintf(tw, "  ~ %s\tchanged\tfile %s, app %s\n", console.ConfigKey(k.Key), k.File, k.App)
+			}
+		}
+		tw.Flush()
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+func TestDriftTargets(t *testing.T) {
+	dir := t.TempDir()
+	project := `{"environments": {
+		"production": {"app": "file:prod.json", "files": ["prod.env"]},
+		"staging": {"app": "file:staging.json", "files": ["staging.env"]}
+	}}`
+	if err := os.WriteFile(filepath.Join(dir, herofig.ProjectFilename), []byte(project), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("HEROFIG_APP", "")
+	t.Setenv("HEROFIG_ENV", "")
+
+	type target struct {
+		app   string
+		files []string
+	}
+	cases := []struct {
+		name string
+		app  string
+		env  string
+		args []string
+		want []target
+	}{
+		{"all environments", "", "", nil, []target{{"file:prod.json", []string{"prod.env"}}, {"file:staging.json", []string{"staging.env"}}}},
+		{"--env", "", "staging", nil, []target{{"file:staging.json", []string{"staging.env"}}}},
+		{"-a", "file:prod.json", "", nil, []target{{"file:prod.json", []string{"prod.env"}}}},
+		{"-a relative path", "file:./staging.json", "", nil, []target{{"file:staging.json", []string{"staging.env"}}}},
+		{"-a with --env", "file:other.json", "staging", nil, []target{{"file:other.json", []string{"staging.env"}}}},
+		{"arguments", "file:prod.json", "", []string{"a.env,b.env=file:x.json"}, []target{{"file:x.json", []string{"a.env", "b.env"}}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			remote := ""
+			ctx := &Context{app: &c.app, env: &c.env, remote: &remote}
+			targets, err := driftTargets(ctx, c.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]target, len(targets))
+			for i, tt := range targets {
+				got[i] = target{tt.app, tt.files}
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("driftTargets() = %v; want %v", got, c.want)
+			}
+		})
+	}
+
+	app, env, remote := "file:dev.json", "", ""
+	ctx := &Context{app: &app, env: &env, remote: &remote}
+	if _, err := driftTargets(ctx, nil); err == nil {
+		t.Error("driftTargets() with an app without environments: expected an error")
+	}
+}
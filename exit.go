@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// Exit statuses of herofig, by kind of error. Errors that are not classified exit with ExitError. ExitDrift is not an
+// error, but reports that drift found differences, so that CI can tell them apart from failures to compare.
+const (
+	ExitError             = 1
+	ExitUsage             = 2
+	ExitAuth              = 3
+	ExitNotFound          = 4
+	ExitPermission        = 5
+	ExitRateLimited       = 6
+	ExitNetwork           = 7
+	ExitValidation        = 8
+	ExitConflict          = 9
+	ExitConfirmationError = 10
+	ExitDrift             = 11
+)
+
+var exitStatuses = map[herofig.ErrorKind]int{
+	herofig.ErrAuth:        ExitAuth,
+	herofig.ErrNotFound:    ExitNotFound,
+	herofig.ErrPermission:  ExitPermission,
+	herofig.ErrRateLimited: ExitRateLimited,
+	herofig.ErrNetwork:     ExitNetwork,
+	herofig.ErrValidation:  ExitValidation,
+	herofig.ErrConflict:    ExitConflict,
+}
+
+// classifyError returns the JSON error code and exit status of err.
+func classifyError(err error) (string, int) {
+	if errors.Is(err, console.ErrNotInteractive) {
+		return "confirmation_required", ExitConfirmationError
+	}
+	kind := herofig.Kind(err)
+	if kind == "" {
+		return "error", ExitError
+	}
+	return string(kind), exitStatuses[kind]
+}
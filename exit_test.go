@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, notExist := os.ReadFile(filepath.Join(t.TempDir(), "missing.env"))
+	_, invalid := herofig.Parse(strings.NewReader("KEY=value\nINVALID\n"))
+
+	cases := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"unclassified", errors.New("failure"), "error", ExitError},
+		{"typed", herofig.Errorf(herofig.ErrAuth, "not logged in"), "auth", ExitAuth},
+		{"wrapped", fmt.Errorf("pushing config: %w", herofig.Errorf(herofig.ErrConflict, "changed")), "conflict", ExitConflict},
+		{"missing file", notExist, "not_found", ExitNotFound},
+		{"parse error", invalid, "validation", ExitValidation},
+		{"not interactive", fmt.Errorf("app.env already exists. %w", console.ErrNotInteractive), "confirmation_required", ExitConfirmationError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			code, status := classifyError(c.err)
+			if code != c.code || status != c.status {
+				t.Errorf("classifyError(%v) = %s, %d; want %s, %d", c.err, code, status, c.code, c.status)
+			}
+		})
+	}
+}
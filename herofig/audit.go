@@ -0,0 +1,249 @@
+package herofig
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// StateDir returns a directory for data kept by herofig in the user state directory ($XDG_STATE_HOME or
+// ~/.local/state, and the user config directory on Windows), creating it if necessary.
+func StateDir(elem ...string) (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" && runtime.GOOS == "windows" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	} else if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	dir = filepath.Join(append([]string{dir, "herofig"}, elem...)...)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Fingerprint returns a short hash of a config value, which can be compared and logged without revealing the value.
+// Values that are short or easily guessed, such as numbers and booleans, can still be recovered by trying them.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// AuditEntry is a change to the config of an application. Values are recorded as fingerprints, so that the audit log
+// does not contain secrets.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// User is the user of the operating system that made the change.
+	User string `json:"user"`
+	// Account is the Heroku account that made the change, if the application is a Heroku application.
+	Account string `json:"account,omitempty"`
+	// App is the application, with the absolute path of file applications.
+	App     string        `json:"app"`
+	Command string        `json:"command,omitempty"`
+	Changes []AuditChange `json:"changes"`
+}
+
+// AuditChange is a variable that was set or unset by a change. Before is empty if the variable was not set before the
+// change, and After is empty if the variable was unset by it.
+type AuditChange struct {
+	Key    string `json:"key"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AuditFilter selects entries of an audit log. Zero fields match any entry. File applications match by their absolute
+// paths.
+type AuditFilter struct {
+	App   string
+	Key   string
+	Since time.Time
+	Until time.Time
+}
+
+func (f AuditFilter) Match(e AuditEntry) bool {
+	switch {
+	case f.App != "" && !SameApp(e.App, f.App):
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.Key != "":
+		return slices.ContainsFunc(e.Changes, func(c AuditChange) bool { return c.Key == f.Key })
+	}
+	return true
+}
+
+// AuditLog is an append-only log of config changes, stored as JSON lines in a file only readable by the current user.
+type AuditLog struct {
+	Path string
+}
+
+// DefaultAuditLog returns the audit log in the user state directory.
+func DefaultAuditLog() (*AuditLog, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{filepath.Join(dir, "audit.log")}, nil
+}
+
+func (l *AuditLog) Append(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the log that match filter, oldest first. A log that does not exist has no entries.
+func (l *AuditLog) Read(filter AuditFilter) ([]AuditEntry, error) {
+	f, err := os.Open(l.Path)
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, Errorf(ErrValidation, "%s line %d: %v", l.Path, line, err)
+		}
+		if filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// AuditedBackend is a Backend that records the changes made through it in an audit log.
+type AuditedBackend struct {
+	Backend
+	Log *AuditLog
+	// User, Account and Command are recorded in each entry.
+	User    string
+	Account string
+	Command string
+}
+
+// SetConfig sets the variables of cfg, and records them in the audit log. The config is read first, to record the
+// values that were replaced.
+func (a *AuditedBackend) SetConfig(ctx context.Context, cfg Config) error {
+	before, err := a.Backend.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.Backend.SetConfig(ctx, cfg); err != nil {
+		return err
+	}
+
+	changes := make([]AuditChange, 0, len(cfg))
+	for _, v := range cfg.Ordered() {
+		c := AuditChange{Key: v.Key, After: Fingerprint(v.Value)}
+		if old, ok := before[v.Key]; ok {
+			c.Before = Fingerprint(old)
+		}
+		changes = append(changes, c)
+	}
+	return a.record(changes)
+}
+
+// Releases returns the releases of the application, or an ErrValidation error if its backend has no releases.
+func (a *AuditedBackend) Releases(ctx context.Context) ([]Release, error) {
+	r, err := a.releaser()
+	if err != nil {
+		return nil, err
+	}
+	return r.Releases(ctx)
+}
+
+// Rollback restores the config of an earlier release, and records the variables it set and unset in the audit log.
+func (a *AuditedBackend) Rollback(ctx context.Context, version int) error {
+	r, err := a.releaser()
+	if err != nil {
+		return err
+	}
+	before, err := a.Backend.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.Rollback(ctx, version); err != nil {
+		return err
+	}
+	after, err := a.Backend.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("the config was rolled back, but reading it to record the change in the audit log failed: %w", err)
+	}
+
+	var changes []AuditChange
+	for _, c := range Diff(before, after) {
+		ac := AuditChange{Key: c.Key}
+		if c.Kind != Added {
+			ac.Before = Fingerprint(c.Old)
+		}
+		if c.Kind != Removed {
+			ac.After = Fingerprint(c.New)
+		}
+		changes = append(changes, ac)
+	}
+	return a.record(changes)
+}
+
+func (a *AuditedBackend) releaser() (Releaser, error) {
+	r, ok := a.Backend.(Releaser)
+	if !ok {
+		return nil, Errorf(ErrValidation, "%s has no releases, which are only kept for %s applications", a.App(), FilePrefix)
+	}
+	return r, nil
+}
+
+// record appends an entry with changes to the audit log.
+func (a *AuditedBackend) record(changes []AuditChange) error {
+	entry := AuditEntry{
+		Time:    time.Now().UTC(),
+		User:    a.User,
+		Account: a.Account,
+		App:     absApp(a.App()),
+		Command: a.Command,
+		Changes: changes,
+	}
+	if entry.Changes == nil {
+		entry.Changes = []AuditChange{}
+	}
+	if err := a.Log.Append(entry); err != nil {
+		return fmt.Errorf("the config was changed, but recording the change in the audit log failed: %w", err)
+	}
+	return nil
+}
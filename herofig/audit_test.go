@@ -0,0 +1,155 @@
+package herofig_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestAuditLog(t *testing.T) {
+	log := &AuditLog{Path: filepath.Join(t.TempDir(), "state", "audit.log")}
+
+	if entries, err := log.Read(AuditFilter{}); err != nil || entries != nil {
+		t.Fatalf("Read() = %v, %v; want no entries for missing log", entries, err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	written := []AuditEntry{
+		{Time: day(1), User: "alice", App: "api", Command: "set", Changes: []AuditChange{{Key: "A", After: Fingerprint("1")}}},
+		{Time: day(2), User: "bob", App: "api", Command: "push", Changes: []AuditChange{{Key: "B", After: Fingerprint("2")}}},
+		{Time: day(3), User: "alice", App: "web", Command: "set", Changes: []AuditChange{{Key: "A", Before: Fingerprint("1"), After: Fingerprint("3")}}},
+	}
+	for _, e := range written {
+		if err := log.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, err := os.Stat(log.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("audit log mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	cases := []struct {
+		name   string
+		filter AuditFilter
+		want   []AuditEntry
+	}{
+		{"all", AuditFilter{}, written},
+		{"app", AuditFilter{App: "api"}, written[:2]},
+		{"key", AuditFilter{Key: "A"}, []AuditEntry{written[0], written[2]}},
+		{"since", AuditFilter{Since: day(2)}, written[1:]},
+		{"until", AuditFilter{Until: day(2)}, written[:1]},
+		{"nothing", AuditFilter{App: "api", Key: "C"}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := log.Read(c.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Read(%+v) = %v; want %v", c.filter, got, c.want)
+			}
+		})
+	}
+}
+
+func TestAuditedBackend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	b, err := NewFileBackend("app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetConfig(ctx, Config{"A": "secret-a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	log := &AuditLog{Path: filepath.Join(dir, "audit.log")}
+	audited := &AuditedBackend{Backend: b, Log: log, User: "alice", Account: "alice@example.com", Command: "set"}
+	if err := audited.SetConfig(ctx, Config{"A": "secret-a2", "B": "secret-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(log.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") {
+		t.Errorf("audit log contains cleartext values: %s", raw)
+	}
+
+	entries, err := log.Read(AuditFilter{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Read() = %v, %v; want 1 entry", entries, err)
+	}
+	e := entries[0]
+	want := []AuditChange{
+		{Key: "A", Before: Fingerprint("secret-a"), After: Fingerprint("secret-a2")},
+		{Key: "B", After: Fingerprint("secret-b")},
+	}
+	abs, err := filepath.Abs("app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.User != "alice" || e.Account != "alice@example.com" || e.App != FilePrefix+abs || e.Command != "set" || !reflect.DeepEqual(e.Changes, want) {
+		t.Errorf("entry = %+v; want app %s and changes %v", e, FilePrefix+abs, want)
+	}
+
+	// File applications are found by any path to the file, from any directory.
+	if err := os.Mkdir("sub", 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir("sub")
+	for _, app := range []string{FilePrefix + "../app.json", FilePrefix + abs} {
+		if entries, err := log.Read(AuditFilter{App: app}); err != nil || len(entries) != 1 {
+			t.Errorf("Read(App: %s) = %v, %v; want 1 entry", app, entries, err)
+		}
+	}
+}
+
+func TestAuditedBackend_Rollback(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	b, err := NewFileBackend("app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetConfig(ctx, Config{"A": "secret-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetConfig(ctx, Config{"A": "secret-a2", "B": "secret-b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	log := &AuditLog{Path: filepath.Join(dir, "audit.log")}
+	audited := &AuditedBackend{Backend: b, Log: log, User: "alice", Command: "rollback"}
+	if err := audited.Rollback(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := log.Read(AuditFilter{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Read() = %v, %v; want 1 entry", entries, err)
+	}
+	want := []AuditChange{
+		{Key: "A", Before: Fingerprint("secret-a2"), After: Fingerprint("secret-a")},
+		{Key: "B", Before: Fingerprint("secret-b")},
+	}
+	if e := entries[0]; e.Command != "rollback" || !reflect.DeepEqual(e.Changes, want) {
+		t.Errorf("entry = %+v; want changes %v", e, want)
+	}
+
+	unreleased := &AuditedBackend{Backend: NewHeroku("my-app"), Log: log}
+	if err := unreleased.Rollback(ctx, 1); Kind(err) != ErrValidation {
+		t.Errorf("Rollback() of a Heroku application error = %v; want %s", err, ErrValidation)
+	}
+}
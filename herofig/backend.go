@@ -0,0 +1,205 @@
+package herofig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Backend stores the config of an application.
+type Backend interface {
+	// App returns the name of the application.
+	App() string
+	Config(ctx context.Context) (Config, error)
+	// ConfigValue returns the value of key, or an ErrNotFound error if it is not set.
+	ConfigValue(ctx context.Context, key string) (string, error)
+	// SetConfig sets the variables of cfg, leaving any other variables unchanged.
+	SetConfig(ctx context.Context, cfg Config) error
+}
+
+// Releaser is implemented by backends that record every change of the config as a numbered release, and can restore
+// the config of an earlier release.
+type Releaser interface {
+	// Releases returns the releases of the application, oldest first.
+	Releases(ctx context.Context) ([]Release, error)
+	// Rollback restores the config of the release with the given version as a new release.
+	Rollback(ctx context.Context, version int) error
+}
+
+// FilePrefix is the prefix of application names that refer to a file, which is used as a FileBackend.
+const FilePrefix = "file:"
+
+// NewBackend returns a FileBackend if app starts with FilePrefix, and a Heroku client otherwise.
+func NewBackend(app string) (Backend, error) {
+	if path, ok := strings.CutPrefix(app, FilePrefix); ok {
+		return NewFileBackend(path)
+	}
+	return NewHeroku(app), nil
+}
+
+// FileBackend is a Backend which stores the config of an application in a local file, in any format that can be
+// both read and written. It can be used to rehearse changes and test scripts without access to Heroku. A file that
+// does not exist is an application without any config, and is created when the config is first set. Values in env
+// files are quoted where necessary, so that values spanning multiple lines can be stored.
+//
+// Like a Heroku application, every change of the config creates a numbered release with a snapshot of the config,
+// which is kept next to the file in a JSON file with the suffix .releases.json.
+type FileBackend struct {
+	path   string
+	format Format
+}
+
+// Release is a numbered version of the config of an application, created by every change of its config.
+type Release struct {
+	Version     int       `json:"version"`
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+	// Config is a snapshot of the config as of the release.
+	Config Config `json:"config"`
+}
+
+var fileBackendOptions = FormatOptions{Separator: "_", Quoted: true}
+
+// NewFileBackend returns a FileBackend for the file at path, in the format detected from its name.
+func NewFileBackend(path string) (*FileBackend, error) {
+	if path == "" {
+		return nil, Errorf(ErrValidation, "missing file name in %s application", FilePrefix)
+	}
+	format := DetectFormat(path)
+	if format.Decode == nil || format.Encode == nil {
+		return nil, Errorf(ErrValidation, "%s files cannot be used as applications", format.Name)
+	}
+	return &FileBackend{path, format}, nil
+}
+
+func (f *FileBackend) App() string {
+	return FilePrefix + f.path
+}
+
+func (f *FileBackend) Config(ctx context.Context) (Config, error) {
+	cfg, err := LoadFormat(f.path, f.format, fileBackendOptions)
+	if isNotExist(err) {
+		return make(Config), nil
+	}
+	return cfg, err
+}
+
+func (f *FileBackend) ConfigValue(ctx context.Context, key string) (string, error) {
+	cfg, err := f.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	v, ok := cfg[key]
+	if !ok {
+		return "", Errorf(ErrNotFound, "%s is not set on %s", key, f.App())
+	}
+	return v, nil
+}
+
+func (f *FileBackend) SetConfig(ctx context.Context, cfg Config) error {
+	existing, err := f.Config(ctx)
+	if err != nil {
+		return err
+	}
+	maps.Copy(existing, cfg)
+
+	keys := slices.Sorted(maps.Keys(cfg))
+	return f.release(existing, fmt.Sprintf("Set %s config vars", strings.Join(keys, ", ")))
+}
+
+func (f *FileBackend) Releases(ctx context.Context) ([]Release, error) {
+	b, err := os.ReadFile(f.releasesPath())
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var releases []Release
+	if err := json.Unmarshal(b, &releases); err != nil {
+		return nil, Errorf(ErrValidation, "parsing %s: %v", f.releasesPath(), err)
+	}
+	return releases, nil
+}
+
+// Release returns the release with the given version, or an ErrNotFound error if there is none.
+func (f *FileBackend) Release(ctx context.Context, version int) (Release, error) {
+	releases, err := f.Releases(ctx)
+	if err != nil {
+		return Release{}, err
+	}
+	for _, r := range releases {
+		if r.Version == version {
+			return r, nil
+		}
+	}
+	return Release{}, Errorf(ErrNotFound, "release v%d of %s not found", version, f.App())
+}
+
+// Rollback restores the config snapshot of the release with the given version, including removing variables that
+// were set since, and creates a new release like Heroku does.
+func (f *FileBackend) Rollback(ctx context.Context, version int) error {
+	r, err := f.Release(ctx, version)
+	if err != nil {
+		return err
+	}
+	return f.release(r.Config, fmt.Sprintf("Rollback to v%d", version))
+}
+
+// release saves cfg as the config of the application, and records it as a new release.
+func (f *FileBackend) release(cfg Config, description string) error {
+	releases, err := f.Releases(context.Background())
+	if err != nil {
+		return err
+	}
+	version := 1
+	if len(releases) > 0 {
+		version = releases[len(releases)-1].Version + 1
+	}
+	releases = append(releases, Release{version, time.Now().UTC(), description, maps.Clone(cfg)})
+
+	err = writeFileAtomic(f.path, func(path string) error {
+		return SaveFormat(path, cfg, f.format, fileBackendOptions)
+	})
+	if err != nil {
+		return fmt.Errorf("saving %s: %w", f.path, err)
+	}
+	b, err := json.MarshalIndent(releases, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(f.releasesPath(), func(path string) error {
+		return os.WriteFile(path, b, 0600)
+	})
+	if err != nil {
+		return fmt.Errorf("saving release v%d of %s: %w", version, f.App(), err)
+	}
+	return nil
+}
+
+func (f *FileBackend) releasesPath() string {
+	return f.path + ".releases.json"
+}
+
+// writeFileAtomic writes path using write, which is called with the path of a temporary file that is renamed to path
+// afterwards, so that path is never left partially written.
+func writeFileAtomic(path string, write func(path string) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := write(tmp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
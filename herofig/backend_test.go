@@ -0,0 +1,149 @@
+package herofig_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestNewBackend(t *testing.T) {
+	cases := []struct {
+		app     string
+		want    Backend
+		wantErr bool
+	}{
+		{"my-app", NewHeroku("my-app"), false},
+		{"file:app.json", &FileBackend{}, false},
+		{"file:app.env", &FileBackend{}, false},
+		{"file:", nil, true},
+		{"file:app.sh", nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.app, func(t *testing.T) {
+			got, err := NewBackend(c.app)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("NewBackend(%s) error = %v; want error %v", c.app, err, c.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(c.want) {
+				t.Errorf("NewBackend(%s) = %T; want %T", c.app, got, c.want)
+			}
+			if got.App() != c.app {
+				t.Errorf("NewBackend(%s).App() = %s", c.app, got.App())
+			}
+		})
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"app.json", "app.env", "app.yaml", "app.toml"} {
+		t.Run(name, func(t *testing.T) {
+			b, err := NewFileBackend(filepath.Join(t.TempDir(), name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := b.Config(ctx)
+			if err != nil || len(cfg) != 0 {
+				t.Fatalf("Config() = %v, %v; want empty config for missing file", cfg, err)
+			}
+			if _, err := b.ConfigValue(ctx, "A"); Kind(err) != ErrNotFound {
+				t.Errorf("ConfigValue(A) error = %v; want %s", err, ErrNotFound)
+			}
+
+			if err := b.SetConfig(ctx, Config{"A": "1", "B": "two words"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.SetConfig(ctx, Config{"B": "2", "C_D": "3", "KEY": "-----BEGIN KEY-----\nabc\n-----END KEY-----\n"}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := Config{"A": "1", "B": "2", "C_D": "3", "KEY": "-----BEGIN KEY-----\nabc\n-----END KEY-----\n"}
+			if cfg, err := b.Config(ctx); err != nil || !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Config() = %v, %v; want %v", cfg, err, want)
+			}
+			if v, err := b.ConfigValue(ctx, "B"); err != nil || v != "2" {
+				t.Errorf("ConfigValue(B) = %q, %v; want 2", v, err)
+			}
+		})
+	}
+}
+
+func TestFileBackend_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFileBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Config(context.Background()); Kind(err) != ErrValidation {
+		t.Errorf("Config() error = %v; want %s", err, ErrValidation)
+	}
+}
+
+func TestFileBackend_Releases(t *testing.T) {
+	ctx := context.Background()
+	b, err := NewFileBackend(filepath.Join(t.TempDir(), "app.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if releases, err := b.Releases(ctx); err != nil || len(releases) != 0 {
+		t.Fatalf("Releases() = %v, %v; want no releases for missing file", releases, err)
+	}
+	if err := b.SetConfig(ctx, Config{"A": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetConfig(ctx, Config{"B": "2", "A": "10"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Rollback(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err := b.Releases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		description string
+		config      Config
+	}{
+		{"Set A config vars", Config{"A": "1"}},
+		{"Set A, B config vars", Config{"A": "10", "B": "2"}},
+		{"Rollback to v1", Config{"A": "1"}},
+	}
+	if len(releases) != len(want) {
+		t.Fatalf("Releases() = %v; want %d releases", releases, len(want))
+	}
+	for i, w := range want {
+		r := releases[i]
+		if r.Version != i+1 || r.Description != w.description || !reflect.DeepEqual(r.Config, w.config) {
+			t.Errorf("Releases()[%d] = v%d %q %v; want v%d %q %v", i, r.Version, r.Description, r.Config, i+1, w.description, w.config)
+		}
+	}
+
+	if cfg, err := b.Config(ctx); err != nil || !reflect.DeepEqual(cfg, Config{"A": "1"}) {
+		t.Errorf("Config() after rollback = %v, %v; want A=1", cfg, err)
+	}
+	if r, err := b.Release(ctx, 2); err != nil || r.Config["B"] != "2" {
+		t.Errorf("Release(2) = %v, %v; want snapshot with B=2", r, err)
+	}
+	if _, err := b.Release(ctx, 4); Kind(err) != ErrNotFound {
+		t.Errorf("Release(4) error = %v; want %s", err, ErrNotFound)
+	}
+	if err := b.Rollback(ctx, 4); Kind(err) != ErrNotFound {
+		t.Errorf("Rollback(4) error = %v; want %s", err, ErrNotFound)
+	}
+}
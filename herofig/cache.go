@@ -0,0 +1,182 @@
+package herofig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// CacheDir returns a directory for data cached by herofig in the user cache directory, creating it if necessary.
+func CacheDir(elem ...string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(append([]string{dir, "herofig"}, elem...)...)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+var herokuAppName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// CacheName returns the name used for cache files of app. Valid Heroku application names are used as is, and other
+// names are hashed, so that names such as ../x cannot refer to files outside the cache directory.
+func CacheName(app string) string {
+	if herokuAppName.MatchString(app) {
+		return app
+	}
+	sum := sha256.Sum256([]byte(app))
+	return "app-" + hex.EncodeToString(sum[:16])
+}
+
+// ConfigCache caches the config of applications on disk, in files that are only readable by the current user.
+type ConfigCache struct {
+	// Dir is the directory configs are cached in. It defaults to a directory in the user cache directory.
+	Dir string
+	// TTL is how long a cached config is used before it is fetched again. Configs are only cached if TTL is positive.
+	TTL time.Duration
+	// Offline serves configs from the cache regardless of their age.
+	Offline bool
+	Warn    func(format string, a ...any)
+	// OnInvalidate is called after the cached config of an application has been invalidated.
+	OnInvalidate func(app string)
+}
+
+type cachedConfig struct {
+	Fetched time.Time `json:"fetched"`
+	// Hash is the hash of Config, used to detect corrupted cache files.
+	Hash   string `json:"hash"`
+	Config Config `json:"config"`
+}
+
+func (c *ConfigCache) enabled() bool {
+	return c.Offline || c.TTL > 0
+}
+
+// Get returns the cached config of app, or nil if it is not cached or has expired. When offline, a config is returned
+// regardless of its age, along with a warning about how old it is, and a missing config is an error.
+func (c *ConfigCache) Get(app string) (Config, error) {
+	if !c.enabled() {
+		return nil, nil
+	}
+
+	cached, err := c.read(app)
+	if err != nil {
+		if c.Offline {
+			return nil, fmt.Errorf("reading cached config of %s: %v", app, err)
+		}
+		return nil, nil
+	}
+	if cached == nil {
+		if c.Offline {
+			return nil, fmt.Errorf("the config of %s is not cached. Enable caching by setting HEROFIG_CACHE_TTL, and run herofig once while online", app)
+		}
+		return nil, nil
+	}
+
+	age := time.Since(cached.Fetched)
+	if c.Offline {
+		c.warn("Offline: using the config of %s cached %s ago.", app, age.Round(time.Second))
+		return cached.Config, nil
+	}
+	if age >= c.TTL {
+		return nil, nil
+	}
+	return cached.Config, nil
+}
+
+// Put caches cfg as the config of app, if caching is enabled.
+func (c *ConfigCache) Put(app string, cfg Config) error {
+	if c.TTL <= 0 {
+		return nil
+	}
+	path, err := c.path(app)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(cachedConfig{time.Now(), fmt.Sprintf("%x", cfg.Hash()), cfg})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a concurrent read never sees a partially written config.
+	f, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Invalidate removes the cached config of app. Heroku calls it after every change of the config, whether caching is
+// enabled or not.
+func (c *ConfigCache) Invalidate(app string) error {
+	path, err := c.path(app)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !isNotExist(err) {
+		return err
+	}
+	if c.OnInvalidate != nil {
+		c.OnInvalidate(app)
+	}
+	return nil
+}
+
+func (c *ConfigCache) read(app string) (*cachedConfig, error) {
+	path, err := c.path(app)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedConfig
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if fmt.Sprintf("%x", cached.Config.Hash()) != cached.Hash {
+		return nil, fmt.Errorf("%s is corrupted: hash mismatch", path)
+	}
+	return &cached, nil
+}
+
+func (c *ConfigCache) warn(format string, a ...any) {
+	if c.Warn != nil {
+		c.Warn(format, a...)
+	}
+}
+
+func (c *ConfigCache) path(app string) (string, error) {
+	dir := c.Dir
+	if dir == "" {
+		var err error
+		dir, err = CacheDir("config")
+		if err != nil {
+			return "", err
+		}
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CacheName(app)+".json"), nil
+}
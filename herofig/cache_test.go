@@ -0,0 +1,134 @@
+package herofig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := Config{"KEY": "value"}
+
+	disabled := &ConfigCache{}
+	if err := disabled.Put("my-app", cfg); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, _ := (&ConfigCache{TTL: time.Minute}).Get("my-app"); got != nil {
+		t.Errorf("Get after Put with caching disabled = %v; want nil", got)
+	}
+
+	cache := &ConfigCache{TTL: time.Minute}
+	if err := cache.Put("my-app", cfg); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := cache.Get("my-app")
+	if err != nil || got["KEY"] != "value" {
+		t.Errorf("Get = %v, %v; want %v", got, err, cfg)
+	}
+
+	path, _ := (&ConfigCache{}).path("my-app")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("cache file mode = %v; want 0600", info.Mode().Perm())
+	}
+
+	if got, _ := (&ConfigCache{TTL: time.Nanosecond}).Get("my-app"); got != nil {
+		t.Errorf("Get of expired config = %v; want nil", got)
+	}
+
+	var warnings []string
+	offline := &ConfigCache{Offline: true, Warn: func(format string, a ...any) {
+		warnings = append(warnings, format)
+	}}
+	if got, err := offline.Get("my-app"); err != nil || got["KEY"] != "value" {
+		t.Errorf("offline Get = %v, %v; want %v", got, err, cfg)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("offline Get printed %d warnings; want 1", len(warnings))
+	}
+
+	if err := cache.Invalidate("my-app"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if got, _ := cache.Get("my-app"); got != nil {
+		t.Errorf("Get after Invalidate = %v; want nil", got)
+	}
+	if _, err := offline.Get("my-app"); err == nil {
+		t.Error("offline Get of uncached config succeeded; want error")
+	}
+}
+
+func TestConfigCache_Corrupted(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cache := &ConfigCache{TTL: time.Minute}
+	if err := cache.Put("my-app", Config{"KEY": "value"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	path, _ := (&ConfigCache{}).path("my-app")
+	b, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(b), `"value"`, `"other"`, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := cache.Get("my-app"); got != nil {
+		t.Errorf("Get of corrupted config = %v; want nil", got)
+	}
+	if _, err := (&ConfigCache{Offline: true}).Get("my-app"); err == nil {
+		t.Error("offline Get of corrupted config succeeded; want error")
+	}
+}
+
+func TestConfigCache_AppNames(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside.json")
+	if err := os.WriteFile(outside, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache := &ConfigCache{Dir: filepath.Join(root, "cache"), TTL: time.Minute}
+
+	cases := []struct {
+		app  string
+		name string
+	}{
+		{"my-app", "my-app.json"},
+		{"../outside", ""},
+		{"../../etc/passwd", ""},
+		{"My-App", ""},
+		{"file:./app.json", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.app, func(t *testing.T) {
+			path, err := cache.path(c.app)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(path) != cache.Dir {
+				t.Errorf("path(%s) = %s; want a file in %s", c.app, path, cache.Dir)
+			}
+			if c.name != "" && filepath.Base(path) != c.name {
+				t.Errorf("path(%s) = %s; want %s", c.app, path, c.name)
+			}
+
+			if err := cache.Put(c.app, Config{"KEY": "value"}); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if got, err := cache.Get(c.app); err != nil || got["KEY"] != "value" {
+				t.Errorf("Get = %v, %v; want cached config", got, err)
+			}
+			if err := cache.Invalidate(c.app); err != nil {
+				t.Fatalf("Invalidate: %v", err)
+			}
+			if _, err := os.Stat(outside); err != nil {
+				t.Errorf("Invalidate(%s) removed a file outside the cache directory: %v", c.app, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package herofig
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/kayex/herofig/internal/hash"
+)
+
+type Config map[string]string
+
+type Var struct {
+	Key   string
+	Value string
+}
+
+func (v Var) String() string {
+	return fmt.Sprintf("%s=%s", v.Key, v.Value)
+}
+
+// EnvLine returns v as a line in an env file, with the value quoted if necessary.
+func (v Var) EnvLine() string {
+	return fmt.Sprintf("%s=%s", v.Key, quote(v.Value))
+}
+
+func (c Config) Hash() hash.Hash {
+	ordered := c.Ordered()
+	lines := make([]string, len(ordered))
+	for i, v := range ordered {
+		lines[i] = v.String()
+	}
+	return hash.New(lines)
+}
+
+// Merge returns a new Config with the variables of cfgs merged from left to right, so that later configs take
+// precedence over earlier ones.
+func Merge(cfgs ...Config) Config {
+	merged := make(Config)
+	for _, cfg := range cfgs {
+		maps.Copy(merged, cfg)
+	}
+	return merged
+}
+
+// Filter returns a new Config with the variables of c whose keys satisfy keep.
+func (c Config) Filter(keep func(key string) bool) Config {
+	filtered := make(Config, len(c))
+	for k, v := range c {
+		if keep(k) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+func (c Config) Ordered() []Var {
+	lines := make([]Var, 0, len(c))
+	for k, v := range c {
+		lines = append(lines, Var{k, v})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Key < lines[j].Key
+	})
+	return lines
+}
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Changed
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Changed:
+		return "changed"
+	case Removed:
+		return "removed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a difference in a single variable between two configs.
+type Change struct {
+	Kind ChangeKind
+	Key  string
+	// Old is the value before the change. It is empty if the variable was added.
+	Old string
+	// New is the value after the change. It is empty if the variable was removed.
+	New string
+}
+
+// Diff returns the changes that turn the config from into to, ordered by key.
+func Diff(from, to Config) []Change {
+	var changes []Change
+	for _, v := range Merge(from, to).Ordered() {
+		old, inFrom := from[v.Key]
+		updated, inTo := to[v.Key]
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Added, v.Key, "", updated})
+		case !inTo:
+			changes = append(changes, Change{Removed, v.Key, old, ""})
+		case old != updated:
+			changes = append(changes, Change{Changed, v.Key, old, updated})
+		}
+	}
+	return changes
+}
+
+// Apply returns a new Config with changes applied to c.
+func (c Config) Apply(changes []Change) Config {
+	applied := Merge(c)
+	for _, ch := range changes {
+		if ch.Kind == Removed {
+			delete(applied, ch.Key)
+		} else {
+			applied[ch.Key] = ch.New
+		}
+	}
+	return applied
+}
+
+// Environ returns the variables of c merged over base, which is a list of environment variables in the form of
+// os.Environ. Variables of base that are overridden by c are left out, and the variables of c are added in order.
+func Environ(base []string, c Config) []string {
+	env := make([]string, 0, len(base)+len(c))
+	for _, v := range base {
+		k, _, _ := strings.Cut(v, "=")
+		if _, ok := c[k]; !ok {
+			env = append(env, v)
+		}
+	}
+	for _, v := range c.Ordered() {
+		env = append(env, v.String())
+	}
+	return env
+}
@@ -0,0 +1,95 @@
+package herofig_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestConfig_Ordered(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want []Var
+	}{
+		{
+			Config{
+				"A": "value",
+				"B": "value",
+				"C": "value",
+			},
+			[]Var{
+				{"A", "value"},
+				{"B", "value"},
+				{"C", "value"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			got := c.cfg.Ordered()
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Ordered() = %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		from Config
+		to   Config
+		want []Change
+	}{
+		{"equal", Config{"A": "1"}, Config{"A": "1"}, nil},
+		{"added", Config{}, Config{"A": "1"}, []Change{{Added, "A", "", "1"}}},
+		{"removed", Config{"A": "1"}, Config{}, []Change{{Removed, "A", "1", ""}}},
+		{
+			"mixed",
+			Config{"A": "1", "B": "2", "C": "3"},
+			Config{"A": "1", "B": "20", "D": "4"},
+			[]Change{{Changed, "B", "2", "20"}, {Removed, "C", "3", ""}, {Added, "D", "", "4"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Diff(c.from, c.to)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Diff(%v, %v) = %v; want %v", c.from, c.to, got, c.want)
+			}
+			if applied := c.from.Apply(got); !reflect.DeepEqual(applied, c.to) {
+				t.Errorf("Apply(Diff(%v, %v)) = %v; want %v", c.from, c.to, applied, c.to)
+			}
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	cases := []struct {
+		name string
+		base []string
+		cfg  Config
+		want []string
+	}{
+		{"empty", nil, Config{}, []string{}},
+		{"replace", nil, Config{"B": "2", "A": "1"}, []string{"A=1", "B=2"}},
+		{
+			"overlay",
+			[]string{"PATH=/bin", "A=0", "C=x=y"},
+			Config{"A": "1", "B": "two words"},
+			[]string{"PATH=/bin", "C=x=y", "A=1", "B=two words"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Environ(c.base, c.cfg)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Environ(%v, %v) = %v; want %v", c.base, c.cfg, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+// Package herofig reads, writes and compares Heroku application config. It provides the env file parser and writer,
+// the file formats supported by the herofig command, .herofig.json project environments, and a Heroku client which
+// runs the Heroku CLI.
+//
+// Functions that produce output take an io.Writer and never print to stdout, and errors can be classified using
+// Kind.
+package herofig
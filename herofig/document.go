@@ -0,0 +1,195 @@
+package herofig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type LineKind int
+
+const (
+	BlankLine LineKind = iota
+	CommentLine
+	VarLine
+)
+
+type Line struct {
+	Kind    LineKind
+	Comment string
+	Var     Var
+	// Quoted is set on variable lines parsed by ParseQuotedDocument, whose values are quoted again when written.
+	Quoted bool
+}
+
+func (l Line) String() string {
+	switch l.Kind {
+	case CommentLine:
+		return l.Comment
+	case VarLine:
+		if l.Quoted {
+			return l.Var.EnvLine()
+		}
+		return l.Var.String()
+	default:
+		return ""
+	}
+}
+
+const includeDirective = "#include "
+
+// Include returns the path of the env file included by l, if l is an #include directive.
+func (l Line) Include() (string, bool) {
+	if l.Kind != CommentLine || !strings.HasPrefix(l.Comment, includeDirective) {
+		return "", false
+	}
+	path := strings.TrimSpace(strings.TrimPrefix(l.Comment, includeDirective))
+	return path, path != ""
+}
+
+// Document is an env file with its comments and layout preserved.
+type Document []Line
+
+// ParseDocument parses an env file with ParseVar, using values verbatim.
+func ParseDocument(env io.Reader) (Document, error) {
+	return parseDocument(env, false)
+}
+
+// ParseQuotedDocument parses an env file with ParseQuotedVar, decoding quoted values.
+func ParseQuotedDocument(env io.Reader) (Document, error) {
+	return parseDocument(env, true)
+}
+
+func parseDocument(env io.Reader, quoted bool) (Document, error) {
+	var doc Document
+	parse := ParseVar
+	if quoted {
+		parse = ParseQuotedVar
+	}
+
+	scanner := bufio.NewScanner(env)
+	scanner.Split(bufio.ScanLines)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		t := strings.TrimSpace(scanner.Text())
+		switch {
+		case t == "":
+			doc = append(doc, Line{Kind: BlankLine})
+		case strings.HasPrefix(t, "#"):
+			doc = append(doc, Line{Kind: CommentLine, Comment: t})
+		default:
+			k, v, err := parse(scanner.Text())
+			if err != nil {
+				return nil, fmt.Errorf("processing line %d: %w", line, err)
+			}
+			doc = append(doc, Line{Kind: VarLine, Var: Var{k, v}, Quoted: quoted})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Config returns the variables defined in d, not including any variables from included files. Later definitions
+// of a key take precedence over earlier ones.
+func (d Document) Config() Config {
+	cfg := make(Config)
+	for _, l := range d {
+		if l.Kind == VarLine {
+			cfg[l.Var.Key] = l.Var.Value
+		}
+	}
+	return cfg
+}
+
+// Apply returns a new Document with changes applied to d. Changed variables are updated in place, removed variables
+// are removed along with any duplicate definitions, and added variables are appended. The values of changed and
+// added variables are quoted where necessary if quoted is true, and written verbatim otherwise.
+func (d Document) Apply(changes []Change, quoted bool) Document {
+	byKey := make(map[string]Change, len(changes))
+	for _, c := range changes {
+		byKey[c.Key] = c
+	}
+
+	applied := make(Document, 0, len(d)+len(changes))
+	for _, l := range d {
+		c, ok := byKey[l.Var.Key]
+		switch {
+		case l.Kind != VarLine || !ok:
+			applied = append(applied, l)
+		case c.Kind != Removed:
+			applied = append(applied, Line{Kind: VarLine, Var: Var{c.Key, c.New}, Quoted: quoted})
+		}
+	}
+	existing := d.Config()
+	for _, c := range changes {
+		if _, ok := existing[c.Key]; !ok && c.Kind != Removed {
+			applied = append(applied, Line{Kind: VarLine, Var: Var{c.Key, c.New}, Quoted: quoted})
+		}
+	}
+	return applied
+}
+
+// Format returns d in canonical form, with duplicate blank lines as well as leading and trailing blank lines
+// removed. If sorted is true, variables are sorted by key within each section of consecutive variable lines.
+func (d Document) Format(sorted bool) Document {
+	formatted := make(Document, 0, len(d))
+	for _, l := range d {
+		if l.Kind == BlankLine && (len(formatted) == 0 || formatted[len(formatted)-1].Kind == BlankLine) {
+			continue
+		}
+		formatted = append(formatted, l)
+	}
+	for len(formatted) > 0 && formatted[len(formatted)-1].Kind == BlankLine {
+		formatted = formatted[:len(formatted)-1]
+	}
+
+	if sorted {
+		start := 0
+		for i := 0; i <= len(formatted); i++ {
+			if i < len(formatted) && formatted[i].Kind == VarLine {
+				continue
+			}
+			section := formatted[start:i]
+			sort.SliceStable(section, func(i, j int) bool {
+				return section[i].Var.Key < section[j].Var.Key
+			})
+			start = i + 1
+		}
+	}
+	return formatted
+}
+
+// CheckVerbatim returns an error if a variable line of d that is not quoted has a value spanning multiple lines, which
+// cannot be written verbatim without corrupting the document.
+func (d Document) CheckVerbatim() error {
+	for _, l := range d {
+		if l.Kind == VarLine && !l.Quoted && strings.ContainsAny(l.Var.Value, "\r\n") {
+			return Errorf(ErrValidation, "the value of %s spans multiple lines, and can only be written quoted (--quoted)", l.Var.Key)
+		}
+	}
+	return nil
+}
+
+func (d Document) Lines() []string {
+	lines := make([]string, len(d))
+	for i, l := range d {
+		lines[i] = l.String()
+	}
+	return lines
+}
+
+func (d Document) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, l := range d.Lines() {
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,135 @@
+package herofig_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestDocument_Format(t *testing.T) {
+	cases := []struct {
+		name   string
+		parse  func(io.Reader) (Document, error)
+		env    string
+		sorted bool
+		want   []string
+	}{
+		{
+			"verbatim values",
+			ParseDocument,
+			" KEY = value\nA=a b\nB= x\nC=\"q\"\n",
+			false,
+			[]string{"KEY= value", "A=a b", "B= x", `C="q"`},
+		},
+		{
+			"quoted values",
+			ParseQuotedDocument,
+			"KEY = value\nQUOTED='some value'\nPLAIN=\"value\"\n",
+			false,
+			[]string{"KEY=value", `QUOTED="some value"`, "PLAIN=value"},
+		},
+		{
+			"blank lines",
+			ParseDocument,
+			"\n\nA=1\n\n\n# Comment\nB=2\n\n",
+			false,
+			[]string{"A=1", "", "# Comment", "B=2"},
+		},
+		{
+			"sorted sections",
+			ParseDocument,
+			"# Section 1\nC=3\nA=1\n# Section 2\nZ=26\nB=2\n",
+			true,
+			[]string{"# Section 1", "A=1", "C=3", "# Section 2", "B=2", "Z=26"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := c.parse(bytes.NewBufferString(c.env))
+			if err != nil {
+				t.Fatalf("parsing %q: %v", c.env, err)
+			}
+			// Formatting must never change the values read from the document.
+			formatted, err := c.parse(bytes.NewReader(doc.Format(c.sorted).Bytes()))
+			if err != nil || !reflect.DeepEqual(formatted.Config(), doc.Config()) {
+				t.Errorf("reparsed Format(%v) = %v, %v; want %v", c.sorted, formatted.Config(), err, doc.Config())
+			}
+
+			got := doc.Format(c.sorted).Lines()
+			if !slices.Equal(got, c.want) {
+				t.Errorf("Format(%v) = %q; want %q", c.sorted, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDocument_Apply(t *testing.T) {
+	env := "# Database\nA=1\nB=2\n\n# Other\nA=3\nC=4\n"
+	changes := []Change{
+		{Kind: Changed, Key: "A", Old: "3", New: "10"},
+		{Kind: Removed, Key: "C", Old: "4"},
+		{Kind: Added, Key: "D", New: "some value"},
+	}
+
+	cases := []struct {
+		name   string
+		parse  func(io.Reader) (Document, error)
+		quoted bool
+		want   []string
+	}{
+		{"verbatim", ParseDocument, false, []string{"# Database", "A=10", "B=2", "", "# Other", "A=10", "D=some value"}},
+		{"quoted", ParseQuotedDocument, true, []string{"# Database", "A=10", "B=2", "", "# Other", "A=10", `D="some value"`}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := c.parse(bytes.NewBufferString(env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := doc.Apply(changes, c.quoted).Lines()
+			if !slices.Equal(got, c.want) {
+				t.Errorf("Apply() = %q; want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDocument_CheckVerbatim(t *testing.T) {
+	changes := []Change{{Kind: Added, Key: "KEY", New: "line 1\nline 2"}}
+	cases := []struct {
+		name    string
+		parse   func(io.Reader) (Document, error)
+		quoted  bool
+		wantErr bool
+	}{
+		{"verbatim", ParseDocument, false, true},
+		{"quoted", ParseQuotedDocument, true, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// An empty document is a file that does not exist yet.
+			doc, err := c.parse(bytes.NewBufferString(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc = doc.Apply(changes, c.quoted)
+			if err := doc.CheckVerbatim(); (err != nil) != c.wantErr {
+				t.Fatalf("CheckVerbatim() error = %v; want error %v", err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			parsed, err := c.parse(bytes.NewReader(doc.Bytes()))
+			if err != nil || parsed.Config()["KEY"] != "line 1\nline 2" {
+				t.Errorf("reparsed KEY = %q, %v; want the multiline value", parsed.Config()["KEY"], err)
+			}
+		})
+	}
+}
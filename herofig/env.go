@@ -0,0 +1,259 @@
+package herofig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ParseVar parses a KEY=value line. The value is used verbatim, including any quotes and surrounding whitespace.
+func ParseVar(v string) (key string, value string, err error) {
+	delimiter := strings.Index(v, "=")
+	if delimiter < 1 {
+		return "", "", Errorf(ErrValidation, "invalid env variable format %q", v)
+	}
+
+	key = strings.TrimSpace(v[:delimiter])
+	if key == "" {
+		return "", "", Errorf(ErrValidation, "invalid env variable format %q", v)
+	}
+	return key, v[delimiter+1:], nil
+}
+
+// ParseQuotedVar parses a KEY=value line like ParseVar, but trims whitespace around the value and decodes single
+// and double-quoted values, as written by fmt.
+func ParseQuotedVar(v string) (key string, value string, err error) {
+	key, value, err = ParseVar(v)
+	if err != nil {
+		return "", "", err
+	}
+	value, err = unquote(strings.TrimSpace(value))
+	if err != nil {
+		return "", "", Errorf(ErrValidation, "invalid value for %s: %v", key, err)
+	}
+	return key, value, nil
+}
+
+func Parse(env io.Reader) (Config, error) {
+	doc, err := ParseDocument(env)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Config(), nil
+}
+
+// Load reads the env file filename, resolving any #include directives relative to the directory of the file.
+// Values are used verbatim.
+func Load(filename string) (Config, error) {
+	return load(filename, ParseDocument, nil)
+}
+
+// LoadQuoted reads the env file filename like Load, but decodes quoted values like ParseQuotedVar.
+func LoadQuoted(filename string) (Config, error) {
+	return load(filename, ParseQuotedDocument, nil)
+}
+
+func load(filename string, parse func(io.Reader) (Document, error), includedFrom []string) (Config, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(includedFrom, abs) {
+		return nil, Errorf(ErrValidation, "include cycle through %s", filename)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing env file %s: %w", filename, err)
+	}
+
+	cfg := make(Config)
+	for _, l := range doc {
+		if l.Kind == VarLine {
+			cfg[l.Var.Key] = l.Var.Value
+			continue
+		}
+		include, ok := l.Include()
+		if !ok {
+			continue
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filename), include)
+		}
+		included, err := load(include, parse, append(includedFrom, abs))
+		if err != nil {
+			return nil, fmt.Errorf("including %s from %s: %w", include, filename, err)
+		}
+		maps.Copy(cfg, included)
+	}
+
+	return cfg, nil
+}
+
+// LoadAll loads the env files filenames and merges them from left to right, so that variables in later files
+// take precedence over variables in earlier files.
+func LoadAll(filenames ...string) (Config, error) {
+	cfgs := make([]Config, 0, len(filenames))
+	for _, filename := range filenames {
+		cfg, err := Load(filename)
+		if err != nil {
+			return nil, err
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return Merge(cfgs...), nil
+}
+
+// ModeFiles returns the env files in dir that apply to mode, in order of increasing precedence, following the
+// .env, .env.local, .env.<mode>, .env.<mode>.local convention used by Next.js and Vite.
+func ModeFiles(dir, mode string) ([]string, error) {
+	candidates := []string{".env", ".env.local", ".env." + mode, ".env." + mode + ".local"}
+
+	var files []string
+	for _, c := range candidates {
+		path := filepath.Join(dir, c)
+		_, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return nil, Errorf(ErrNotFound, "no env files found for mode %q in %s", mode, dir)
+	}
+	return files, nil
+}
+
+func Save(filename string, cfg Config) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Write(f, cfg)
+}
+
+// Write writes cfg to w as KEY=value lines, with values written verbatim.
+func Write(w io.Writer, cfg Config) error {
+	return write(w, cfg, Var.String)
+}
+
+// WriteQuoted writes cfg to w like Write, but quotes values where necessary like fmt does.
+func WriteQuoted(w io.Writer, cfg Config) error {
+	return write(w, cfg, Var.EnvLine)
+}
+
+func write(w io.Writer, cfg Config, line func(Var) string) error {
+	for _, v := range cfg.Ordered() {
+		_, err := fmt.Fprintln(w, line(v))
+		if err != nil {
+			return fmt.Errorf("writing env line %q: %v", v, err)
+		}
+	}
+	return nil
+}
+
+func FindEnvFiles(root string) ([]string, error) {
+	extension := ".env"
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(d.Name()) == extension {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching for .env files: %v", err)
+	}
+	return paths, nil
+}
+
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// unquote returns the value of a single or double-quoted env value. Double-quoted values support the escape
+// sequences produced by quote. Unquoted values are returned as is.
+func unquote(v string) (string, error) {
+	if len(v) == 0 || (v[0] != '"' && v[0] != '\'') {
+		return v, nil
+	}
+	q := v[0]
+	if len(v) < 2 || v[len(v)-1] != q {
+		return "", fmt.Errorf("unterminated quoted value %s", v)
+	}
+	v = v[1 : len(v)-1]
+	if q == '\'' {
+		return v, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] != '\\' || i == len(v)-1 {
+			b.WriteByte(v[i])
+			continue
+		}
+		i++
+		switch v[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(v[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(v[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// quote returns v in the canonical env file representation, which is unquoted unless v contains whitespace,
+// quotes, comment markers or backslashes, in which case it is double-quoted and escaped.
+func quote(v string) string {
+	if !strings.ContainsAny(v, " \t\r\n\"'#\\") {
+		return v
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range v {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,227 @@
+package herofig_test
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestParseVar(t *testing.T) {
+	cases := []struct {
+		v     string
+		key   string
+		value string
+	}{
+		{"KEY=value", "KEY", "value"},
+		{"KEY=value=value", "KEY", "value=value"},
+		{" KEY=value", "KEY", "value"},
+		{"KEY = value ", "KEY", " value "},
+		{"KEY='some value'", "KEY", "'some value'"},
+		{`KEY="value`, "KEY", `"value`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.v, func(t *testing.T) {
+			key, value, err := ParseVar(c.v)
+			if err != nil {
+				t.Fatalf("ParseVar(%s): %v", c.v, err)
+			}
+
+			if key != c.key || value != c.value {
+				t.Errorf("ParseVar(%s) = %s, %s; got %s, %s", c.v, c.key, c.value, key, value)
+			}
+		})
+	}
+}
+
+func TestParseQuotedVar(t *testing.T) {
+	cases := []struct {
+		v     string
+		key   string
+		value string
+	}{
+		{"KEY=value=value", "KEY", "value=value"},
+		{"KEY = value ", "KEY", "value"},
+		{"KEY='some value'", "KEY", "some value"},
+		{`KEY="line 1\nline \"2\""`, "KEY", "line 1\nline \"2\""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.v, func(t *testing.T) {
+			key, value, err := ParseQuotedVar(c.v)
+			if err != nil {
+				t.Fatalf("ParseQuotedVar(%s): %v", c.v, err)
+			}
+
+			if key != c.key || value != c.value {
+				t.Errorf("ParseQuotedVar(%s) = %s, %s; got %s, %s", c.v, c.key, c.value, key, value)
+			}
+		})
+	}
+}
+
+func TestParseVar_Errors(t *testing.T) {
+	cases := []struct {
+		name  string
+		v     string
+		parse func(string) (string, string, error)
+	}{
+		{"no key", "=value", ParseVar},
+		{"no key quoted", "=value", ParseQuotedVar},
+		{"unterminated quote", `KEY="value`, ParseQuotedVar},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key, value, err := c.parse(c.v)
+			if err == nil {
+				t.Errorf("parse(%s) = %q, %q, %v; want error", c.v, key, value, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		e    string
+		want Config
+	}{
+		{
+			`KEY1=value
+
+			KEY2=value`,
+			Config{
+				"KEY1": "value",
+				"KEY2": "value",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			cfg, err := Parse(bytes.NewBufferString(c.e))
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", c.e, err)
+			}
+
+			if !maps.Equal(cfg, c.want) {
+				t.Errorf("Parse(%q) = %v; want %v", c.e, cfg, c.want)
+			}
+		})
+	}
+}
+
+func TestVar_EnvLine(t *testing.T) {
+	cases := []struct {
+		v    Var
+		want string
+	}{
+		{Var{"KEY", "value"}, "KEY=value"},
+		{Var{"KEY", ""}, "KEY="},
+		{Var{"KEY", "some value"}, `KEY="some value"`},
+		{Var{"KEY", "line 1\nline \"2\""}, `KEY="line 1\nline \"2\""`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			got := c.v.EnvLine()
+			if got != c.want {
+				t.Errorf("EnvLine() = %s; want %s", got, c.want)
+			}
+
+			key, value, err := ParseQuotedVar(got)
+			if err != nil || key != c.v.Key || value != c.v.Value {
+				t.Errorf("ParseQuotedVar(%s) = %q, %q, %v; want %q, %q", got, key, value, err, c.v.Key, c.v.Value)
+			}
+		})
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.env":       "A=base\nB=base\nC=base\n",
+		"shared.env":     "B=shared\nD=shared\n",
+		"staging.env":    "#include shared.env\nC=staging\n",
+		"production.env": "C=production\n#include shared.env\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		files []string
+		want  Config
+	}{
+		{
+			[]string{"base.env", "staging.env"},
+			Config{"A": "base", "B": "shared", "C": "staging", "D": "shared"},
+		},
+		{
+			[]string{"staging.env", "base.env"},
+			Config{"A": "base", "B": "base", "C": "base", "D": "shared"},
+		},
+		{
+			[]string{"base.env", "production.env"},
+			Config{"A": "base", "B": "shared", "C": "production", "D": "shared"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(strings.Join(c.files, ","), func(t *testing.T) {
+			paths := make([]string, len(c.files))
+			for i, f := range c.files {
+				paths[i] = filepath.Join(dir, f)
+			}
+
+			cfg, err := LoadAll(paths...)
+			if err != nil {
+				t.Fatalf("LoadAll(%v): %v", c.files, err)
+			}
+			if !maps.Equal(cfg, c.want) {
+				t.Errorf("LoadAll(%v) = %v; want %v", c.files, cfg, c.want)
+			}
+		})
+	}
+}
+
+func TestLoad_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.env"), []byte("#include b.env\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.env"), []byte("#include a.env\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(filepath.Join(dir, "a.env"))
+	if err == nil {
+		t.Errorf("Load(a.env) = %v, %v; want error", cfg, err)
+	}
+}
+
+func TestModeFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".env", ".env.staging", ".env.staging.local", ".env.production"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ModeFiles(dir, "staging")
+	if err != nil {
+		t.Fatalf("ModeFiles(staging): %v", err)
+	}
+	want := []string{filepath.Join(dir, ".env"), filepath.Join(dir, ".env.staging"), filepath.Join(dir, ".env.staging.local")}
+	if !slices.Equal(files, want) {
+		t.Errorf("ModeFiles(staging) = %v; want %v", files, want)
+	}
+}
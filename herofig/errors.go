@@ -0,0 +1,61 @@
+package herofig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrorKind classifies errors, so that callers can tell them apart without inspecting their messages.
+type ErrorKind string
+
+const (
+	// ErrAuth means that the user is not logged into the Heroku CLI.
+	ErrAuth ErrorKind = "auth"
+	// ErrNotFound means that an application, environment, file or config key does not exist.
+	ErrNotFound ErrorKind = "not_found"
+	// ErrPermission means that the user is not allowed to read or change something.
+	ErrPermission ErrorKind = "permission"
+	// ErrRateLimited means that the Heroku API rate limit was exceeded.
+	ErrRateLimited ErrorKind = "rate_limited"
+	// ErrNetwork means that Heroku could not be reached.
+	ErrNetwork ErrorKind = "network"
+	// ErrValidation means that a config file, project file or config value is invalid.
+	ErrValidation ErrorKind = "validation"
+	// ErrConflict means that the config was changed by someone else in the meantime.
+	ErrConflict ErrorKind = "conflict"
+)
+
+// Error is an error of a known kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// Errorf returns an Error of the given kind with a formatted message. The %w verb is supported as in fmt.Errorf.
+func Errorf(kind ErrorKind, format string, a ...any) *Error {
+	return &Error{kind, fmt.Errorf(format, a...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Kind returns the kind of err, or an empty string if it is not known. Missing files and denied file access are
+// classified even if they are not wrapped in an Error.
+func Kind(err error) ErrorKind {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e.Kind
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package herofig
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kayex/herofig/internal/yaml"
+)
+
+var SecretFormat = Format{
+	Name: "secret",
+	Decode: func(r io.Reader, opts FormatOptions) (Config, error) {
+		return decodeKubernetes(r, "Secret", opts)
+	},
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		return encodeKubernetes(w, "Secret", cfg, opts)
+	},
+}
+
+var ConfigMapFormat = Format{
+	Name: "configmap",
+	Decode: func(r io.Reader, opts FormatOptions) (Config, error) {
+		return decodeKubernetes(r, "ConfigMap", opts)
+	},
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		return encodeKubernetes(w, "ConfigMap", cfg, opts)
+	},
+}
+
+// DockerFormat writes the env file format read by docker run --env-file, which does not support quoting.
+var DockerFormat = Format{
+	Name: "docker",
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		for _, v := range cfg.Ordered() {
+			if strings.ContainsAny(v.Value, "\r\n") {
+				opts.warn("Skipping %s: docker env files cannot contain multiline values", v.Key)
+				continue
+			}
+			if strings.TrimSpace(v.Key) != v.Key || strings.HasPrefix(v.Key, "#") {
+				opts.warn("Skipping %s: not a valid docker env file key", v.Key)
+				continue
+			}
+			if _, err := fmt.Fprintln(w, v.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// SystemdFormat writes the format read by the EnvironmentFile directive of systemd units.
+var SystemdFormat = Format{
+	Name: "systemd",
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		for _, v := range cfg.Ordered() {
+			if !shellIdentifier.MatchString(v.Key) {
+				opts.warn("Skipping %s: not a valid systemd environment variable name", v.Key)
+				continue
+			}
+			if strings.ContainsAny(v.Value, "\r\n") {
+				opts.warn("Skipping %s: systemd environment files cannot contain multiline values", v.Key)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s=%s\n", v.Key, systemdQuote(v.Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// TerraformFormat writes a heroku_app resource with the config as its sensitive_config_vars.
+var TerraformFormat = Format{
+	Name:       "terraform",
+	Extensions: []string{".tf"},
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		var b strings.Builder
+		fmt.Fprintf(&b, "resource \"heroku_app\" %s {\n", hclString(terraformName(opts.Name)))
+		b.WriteString("  sensitive_config_vars = {\n")
+		for _, v := range cfg.Ordered() {
+			key := v.Key
+			if !terraformIdentifier.MatchString(key) {
+				key = hclString(key)
+			}
+			fmt.Fprintf(&b, "    %s = %s\n", key, hclString(v.Value))
+		}
+		b.WriteString("  }\n}\n")
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	},
+}
+
+var kubernetesKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+func encodeKubernetes(w io.Writer, kind string, cfg Config, opts FormatOptions) error {
+	metadata := yaml.MapSlice{{Key: "name", Value: opts.Name}}
+	if opts.Namespace != "" {
+		metadata = append(metadata, yaml.MapItem{Key: "namespace", Value: opts.Namespace})
+	}
+	manifest := yaml.MapSlice{
+		{Key: "apiVersion", Value: "v1"},
+		{Key: "kind", Value: kind},
+		{Key: "metadata", Value: metadata},
+	}
+	if kind == "Secret" {
+		manifest = append(manifest, yaml.MapItem{Key: "type", Value: "Opaque"})
+	}
+
+	data := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		if !kubernetesKey.MatchString(k) {
+			opts.warn("Skipping %s: Kubernetes %s keys may only contain alphanumeric characters, '-', '_' and '.'", k, kind)
+			continue
+		}
+		if kind == "Secret" && !opts.StringData {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		data[k] = v
+	}
+
+	dataKey := "data"
+	if kind == "Secret" && opts.StringData {
+		dataKey = "stringData"
+	}
+	manifest = append(manifest, yaml.MapItem{Key: dataKey, Value: data})
+
+	out, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// systemdQuote double-quotes s if it contains characters that systemd would otherwise strip or interpret.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\#;") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+var terraformIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// terraformName returns name as a valid Terraform resource name.
+func terraformName(name string) string {
+	n := regexp.MustCompile(`[^A-Za-z0-9_-]`).ReplaceAllString(name, "_")
+	if !terraformIdentifier.MatchString(n) {
+		n = "_" + n
+	}
+	return n
+}
+
+// hclString returns s as a quoted HCL string, escaping template sequences so that s is used literally.
+func hclString(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,124 @@
+package herofig_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestExportFormats(t *testing.T) {
+	cfg := Config{
+		"KEY":       "value",
+		"SPACED":    "some value",
+		"MULTILINE": "line 1\nline 2",
+		"TEMPLATE":  "${var}",
+		"NOT VALID": "value",
+	}
+	opts := FormatOptions{Name: "my-app", Namespace: "web"}
+
+	cases := []struct {
+		format   Format
+		opts     FormatOptions
+		want     string
+		warnings int
+	}{
+		{
+			SecretFormat,
+			opts,
+			`apiVersion: "v1"
+kind: "Secret"
+metadata:
+  name: "my-app"
+  namespace: "web"
+type: "Opaque"
+data:
+  KEY: "dmFsdWU="
+  MULTILINE: "bGluZSAxCmxpbmUgMg=="
+  SPACED: "c29tZSB2YWx1ZQ=="
+  TEMPLATE: "JHt2YXJ9"
+`,
+			1,
+		},
+		{
+			SecretFormat,
+			FormatOptions{Name: "my-app", StringData: true},
+			`apiVersion: "v1"
+kind: "Secret"
+metadata:
+  name: "my-app"
+type: "Opaque"
+stringData:
+  KEY: "value"
+  MULTILINE: "line 1\nline 2"
+  SPACED: "some value"
+  TEMPLATE: "${var}"
+`,
+			1,
+		},
+		{
+			ConfigMapFormat,
+			opts,
+			`apiVersion: "v1"
+kind: "ConfigMap"
+metadata:
+  name: "my-app"
+  namespace: "web"
+data:
+  KEY: "value"
+  MULTILINE: "line 1\nline 2"
+  SPACED: "some value"
+  TEMPLATE: "${var}"
+`,
+			1,
+		},
+		{
+			DockerFormat,
+			opts,
+			"KEY=value\nNOT VALID=value\nSPACED=some value\nTEMPLATE=${var}\n",
+			1,
+		},
+		{
+			SystemdFormat,
+			opts,
+			"KEY=value\nSPACED=\"some value\"\nTEMPLATE=${var}\n",
+			2,
+		},
+		{
+			TerraformFormat,
+			opts,
+			`resource "heroku_app" "my-app" {
+  sensitive_config_vars = {
+    KEY = "value"
+    MULTILINE = "line 1\nline 2"
+    "NOT VALID" = "value"
+    SPACED = "some value"
+    TEMPLATE = "$${var}"
+  }
+}
+`,
+			0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format.Name, func(t *testing.T) {
+			var warnings []string
+			c.opts.Warn = func(format string, a ...any) {
+				warnings = append(warnings, fmt.Sprintf(format, a...))
+			}
+
+			var buf bytes.Buffer
+			if err := c.format.Encode(&buf, cfg, c.opts); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if buf.String() != c.want {
+				t.Errorf("Encode(%v) = %s; want %s", cfg, buf.String(), c.want)
+			}
+			if len(warnings) != c.warnings {
+				t.Errorf("Encode(%v) warned %q; want %d warnings", cfg, warnings, c.warnings)
+			}
+		})
+	}
+}
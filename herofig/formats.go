@@ -0,0 +1,329 @@
+package herofig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kayex/herofig/internal/toml"
+	"github.com/kayex/herofig/internal/yaml"
+)
+
+type FormatOptions struct {
+	// Separator is used to join the keys of nested objects when flattening them into config keys.
+	Separator string
+	// Name is the name of the exported resource, such as a Kubernetes Secret or a Terraform heroku_app. When
+	// importing Kubernetes manifests, only manifests with this name are read.
+	Name string
+	// Namespace is the Kubernetes namespace of exported manifests.
+	Namespace string
+	// StringData exports Kubernetes Secrets using stringData instead of base64-encoded data.
+	StringData bool
+	// Service is the docker-compose service to import the environment of.
+	Service string
+	// Dir is the directory that relative paths in the imported file are resolved against.
+	Dir string
+	// Warn is called with a message for each value that cannot be represented in the format.
+	Warn func(format string, a ...any)
+	// Header is written as a comment at the top of saved files, in formats that support comments.
+	Header string
+	// Quoted decodes quoted values in env files and quotes values where necessary when writing them, like fmt.
+	// Otherwise env values are read and written verbatim.
+	Quoted bool
+}
+
+func (o FormatOptions) warn(format string, a ...any) {
+	if o.Warn != nil {
+		o.Warn(format, a...)
+	}
+}
+
+// Format is a file format that config can be decoded from and/or encoded to.
+type Format struct {
+	Name       string
+	Extensions []string
+	// Filenames are file names that are detected as the format regardless of their extension.
+	Filenames []string
+	Decode    func(r io.Reader, opts FormatOptions) (Config, error)
+	Encode    func(w io.Writer, cfg Config, opts FormatOptions) error
+	// Comment is the prefix of comment lines, if the format can be read with comments.
+	Comment string
+}
+
+var EnvFormat = Format{
+	Name:       "env",
+	Extensions: []string{".env"},
+	Comment:    "#",
+	Decode: func(r io.Reader, opts FormatOptions) (Config, error) {
+		if opts.Quoted {
+			doc, err := ParseQuotedDocument(r)
+			if err != nil {
+				return nil, err
+			}
+			return doc.Config(), nil
+		}
+		return Parse(r)
+	},
+	Encode: func(w io.Writer, cfg Config, opts FormatOptions) error {
+		if opts.Quoted {
+			return WriteQuoted(w, cfg)
+		}
+		return Write(w, cfg)
+	},
+}
+
+var Formats = []Format{
+	EnvFormat,
+	{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Decode:     decodeJSON,
+		Encode:     encodeJSON,
+	},
+	{
+		Name:       "yaml",
+		Extensions: []string{".yaml", ".yml"},
+		Decode:     decodeYAML,
+		Encode:     encodeYAML,
+		Comment:    "#",
+	},
+	{
+		Name:       "toml",
+		Extensions: []string{".toml"},
+		Decode:     decodeTOML,
+		Encode:     encodeTOML,
+		Comment:    "#",
+	},
+	ShFormat,
+	FishFormat,
+	PowerShellFormat,
+	DirenvFormat,
+	GitHubEnvFormat,
+	SecretFormat,
+	ConfigMapFormat,
+	DockerFormat,
+	SystemdFormat,
+	TerraformFormat,
+	ComposeFormat,
+	HerokuFormat,
+}
+
+func FormatByName(name string) (Format, error) {
+	var names []string
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, nil
+		}
+		names = append(names, f.Name)
+	}
+	return Format{}, Errorf(ErrValidation, "unknown format %q (available formats: %s)", name, strings.Join(names, ", "))
+}
+
+// FormatNames returns the names of the formats that can be decoded from, or encoded to if encode is true.
+func FormatNames(encode bool) string {
+	var names []string
+	for _, f := range Formats {
+		if (encode && f.Encode != nil) || (!encode && f.Decode != nil) {
+			names = append(names, f.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// DetectFormat returns the format of filename based on its name or extension, defaulting to EnvFormat.
+func DetectFormat(filename string) Format {
+	for _, f := range Formats {
+		if slices.Contains(f.Filenames, filepath.Base(filename)) {
+			return f
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, f := range Formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f
+			}
+		}
+	}
+	return EnvFormat
+}
+
+// LoadFormat loads filename using format f. Env files are loaded using Load, which resolves #include directives.
+func LoadFormat(filename string, f Format, opts FormatOptions) (Config, error) {
+	if f.Decode == nil {
+		return nil, fmt.Errorf("config cannot be read from %s", f.Name)
+	}
+	if f.Name == EnvFormat.Name && opts.Quoted {
+		return LoadQuoted(filename)
+	}
+	if f.Name == EnvFormat.Name {
+		return Load(filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	opts.Dir = filepath.Dir(filename)
+	cfg, err := f.Decode(file, opts)
+	if err != nil {
+		return nil, Errorf(ErrValidation, "parsing %s file %s: %w", f.Name, filename, err)
+	}
+	return cfg, nil
+}
+
+// SaveFormat writes cfg to filename using format f.
+func SaveFormat(filename string, cfg Config, f Format, opts FormatOptions) error {
+	if f.Encode == nil {
+		return fmt.Errorf("config cannot be written as %s", f.Name)
+	}
+
+	var buf bytes.Buffer
+	if opts.Header != "" && f.Comment != "" {
+		for _, l := range strings.Split(opts.Header, "\n") {
+			fmt.Fprintf(&buf, "%s %s\n", f.Comment, l)
+		}
+	}
+	if err := f.Encode(&buf, cfg, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// Flatten converts a decoded document into a Config, joining the keys of nested objects using separator. Arrays
+// are stored as JSON. Nested keys that flatten to the same key as another key are an ErrValidation error.
+func Flatten(v any, separator string) (Config, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object at the top level")
+	}
+
+	cfg := make(Config)
+	err := flatten(cfg, "", m, separator)
+	return cfg, err
+}
+
+func flatten(cfg Config, prefix string, m map[string]any, separator string) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + separator + k
+		}
+
+		if _, ok := v.(map[string]any); !ok {
+			if _, ok := cfg[key]; ok {
+				return Errorf(ErrValidation, "%s is defined more than once when nested keys are joined with %q", key, separator)
+			}
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(cfg, key, v, separator); err != nil {
+				return err
+			}
+			continue
+		case []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("encoding array %s: %v", key, err)
+			}
+			cfg[key] = string(b)
+		case string:
+			cfg[key] = v
+		case json.Number:
+			cfg[key] = v.String()
+		case bool:
+			cfg[key] = strconv.FormatBool(v)
+		case nil:
+			cfg[key] = ""
+		default:
+			return fmt.Errorf("unsupported value for %s: %v", key, v)
+		}
+	}
+	return nil
+}
+
+func decodeJSON(r io.Reader, opts FormatOptions) (Config, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return Flatten(v, opts.Separator)
+}
+
+func encodeJSON(w io.Writer, cfg Config, _ FormatOptions) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// decodeYAML flattens the first document in r, unless r contains Kubernetes Secret or ConfigMap manifests, which are
+// read like SecretFormat and ConfigMapFormat.
+func decodeYAML(r io.Reader, opts FormatOptions) (Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := yaml.DecodeAll(data)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := kubernetesKind(docs)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "" {
+		return kubernetesConfig(docs, kind, opts)
+	}
+	if len(docs) == 0 {
+		return Config{}, nil
+	}
+	return Flatten(docs[0], opts.Separator)
+}
+
+func encodeYAML(w io.Writer, cfg Config, _ FormatOptions) error {
+	m := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		m[k] = v
+	}
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func decodeTOML(r io.Reader, opts FormatOptions) (Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := toml.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return Flatten(m, opts.Separator)
+}
+
+func encodeTOML(w io.Writer, cfg Config, _ FormatOptions) error {
+	for _, v := range cfg.Ordered() {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", toml.Key(v.Key), toml.String(v.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package herofig_test
+
+import (
+	"bytes"
+	"maps"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestFormat_Decode(t *testing.T) {
+	cases := []struct {
+		format    string
+		input     string
+		separator string
+		want      Config
+	}{
+		{
+			"json",
+			`{"KEY": "value", "PORT": 5432, "DEBUG": false, "AWS": {"S3": {"BUCKET": "bucket"}}, "HOSTS": ["a", "b"]}`,
+			"_",
+			Config{"KEY": "value", "PORT": "5432", "DEBUG": "false", "AWS_S3_BUCKET": "bucket", "HOSTS": `["a","b"]`},
+		},
+		{
+			"yaml",
+			"AWS:\n  S3_BUCKET: bucket\n  REGION: eu-north-1\nKEY: value\n",
+			"__",
+			Config{"AWS__S3_BUCKET": "bucket", "AWS__REGION": "eu-north-1", "KEY": "value"},
+		},
+		{
+			"toml",
+			"KEY = \"value\"\n[AWS]\nS3_BUCKET = \"bucket\"\n",
+			"_",
+			Config{"KEY": "value", "AWS_S3_BUCKET": "bucket"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			f, err := FormatByName(c.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := f.Decode(bytes.NewBufferString(c.input), FormatOptions{Separator: c.separator})
+			if err != nil {
+				t.Fatalf("Decode(%q): %v", c.input, err)
+			}
+			if !maps.Equal(cfg, c.want) {
+				t.Errorf("Decode(%q) = %v; want %v", c.input, cfg, c.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Decode_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"nested key collides with flat key", "json", `{"AWS": {"S3": "nested"}, "AWS_S3": "flat"}`},
+		{"nested keys collide", "yaml", "A:\n  B_C: 1\nA_B:\n  C: 2\n"},
+		{"tab indentation", "yaml", "AWS:\n\tS3: bucket\n"},
+		{"bare toml value", "toml", "KEY = nope\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := FormatByName(c.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Collisions must be reported regardless of the order in which keys are visited.
+			for range 10 {
+				cfg, err := f.Decode(bytes.NewBufferString(c.input), FormatOptions{Separator: "_"})
+				if err == nil {
+					t.Fatalf("Decode(%q) = %v; want error", c.input, cfg)
+				}
+			}
+		})
+	}
+}
+
+func TestFormat_RoundTrip(t *testing.T) {
+	cfg := Config{
+		"KEY":       "value",
+		"QUOTED":    `some "quoted" value`,
+		"MULTILINE": "line 1\nline 2",
+		"EMPTY":     "",
+		"NUMBER":    "5432",
+	}
+
+	for _, f := range Formats {
+		if f.Decode == nil || f.Encode == nil {
+			continue
+		}
+		t.Run(f.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// Multiline values can only be written to env files when they are quoted.
+			if err := f.Encode(&buf, cfg, FormatOptions{Quoted: true}); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			decoded, err := f.Decode(&buf, FormatOptions{Separator: "_", Quoted: true})
+			if err != nil {
+				t.Fatalf("Decode(%q): %v", buf.String(), err)
+			}
+			if !maps.Equal(decoded, cfg) {
+				t.Errorf("Decode(Encode(%v)) = %v", cfg, decoded)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]string{
+		"app.env":         "env",
+		".env.local":      "env",
+		"config.json":     "json",
+		"config.YML":      "yaml",
+		"config.yaml":     "yaml",
+		"config.toml":     "toml",
+		"config.unknown":  "env",
+		"dir.json/config": "env",
+	}
+
+	for filename, want := range cases {
+		t.Run(filename, func(t *testing.T) {
+			if got := DetectFormat(filename).Name; got != want {
+				t.Errorf("DetectFormat(%s) = %s; want %s", filename, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package herofig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type GitRemote struct {
+	Name string
+	URL  string
+}
+
+var herokuRemoteURL = regexp.MustCompile(`^(?:https://git\.heroku\.com/|ssh://git@heroku\.com/|git@heroku\.com:)([a-z0-9-]+)\.git$`)
+
+// HerokuApp returns the name of the Heroku application of a git remote URL.
+func HerokuApp(url string) (string, bool) {
+	m := herokuRemoteURL.FindStringSubmatch(url)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// InferApp returns the Heroku application of the git repository containing dir, based on its Heroku git remotes.
+// If remote is given, the application of that remote is returned. Otherwise, the remote named heroku is preferred
+// if there are several, as in the Heroku CLI. An empty string is returned if there are no Heroku remotes.
+func InferApp(dir, remote string) (string, error) {
+	configPath, err := FindGitConfig(dir)
+	if err != nil {
+		return "", err
+	}
+	if configPath == "" {
+		if remote != "" {
+			return "", Errorf(ErrNotFound, "git remote %s not found: not in a git repository", remote)
+		}
+		return "", nil
+	}
+
+	remotes, err := GitRemotes(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []GitRemote
+	for _, r := range remotes {
+		if _, ok := HerokuApp(r.URL); ok {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if remote != "" {
+		for _, r := range remotes {
+			if r.Name != remote {
+				continue
+			}
+			app, ok := HerokuApp(r.URL)
+			if !ok {
+				return "", fmt.Errorf("git remote %s (%s) is not a Heroku git remote", r.Name, r.URL)
+			}
+			return app, nil
+		}
+		return "", Errorf(ErrNotFound, "git remote %s not found (Heroku git remotes: %s)", remote, describeRemotes(candidates))
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", nil
+	case 1:
+		app, _ := HerokuApp(candidates[0].URL)
+		return app, nil
+	}
+	for _, r := range candidates {
+		if r.Name == "heroku" {
+			app, _ := HerokuApp(r.URL)
+			return app, nil
+		}
+	}
+	return "", fmt.Errorf("multiple Heroku git remotes found, select one with --remote or the application with --app: %s", describeRemotes(candidates))
+}
+
+func describeRemotes(remotes []GitRemote) string {
+	if len(remotes) == 0 {
+		return "none"
+	}
+	descriptions := make([]string, len(remotes))
+	for i, r := range remotes {
+		app, _ := HerokuApp(r.URL)
+		descriptions[i] = fmt.Sprintf("%s (%s)", r.Name, app)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// FindGitConfig returns the path of the config file of the git repository containing dir, or an empty string if
+// dir is not in a git repository.
+func FindGitConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		info, err := os.Stat(gitDir)
+		if err == nil {
+			if !info.IsDir() {
+				// Worktrees and submodules have a .git file pointing to the actual git directory.
+				gitDir, err = readGitDirFile(gitDir)
+				if err != nil {
+					return "", err
+				}
+			}
+			return filepath.Join(commonGitDir(gitDir), "config"), nil
+		}
+		if !isNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func readGitDirFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("invalid .git file %s", path)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+// commonGitDir returns the git directory containing the config shared by all worktrees of the repository.
+func commonGitDir(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	common := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return common
+}
+
+// GitRemotes returns the remotes defined in the git config file at path.
+func GitRemotes(path string) ([]GitRemote, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	section := regexp.MustCompile(`^\[\s*([A-Za-z0-9.-]+)(?:\s+"((?:[^"\\]|\\.)*)")?\s*]`)
+	var remotes []GitRemote
+	var remote string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		t := strings.TrimSpace(scanner.Text())
+		if t == "" || t[0] == '#' || t[0] == ';' {
+			continue
+		}
+
+		if m := section.FindStringSubmatch(t); m != nil {
+			remote = ""
+			if strings.EqualFold(m[1], "remote") {
+				remote = m[2]
+			}
+			continue
+		}
+		if remote == "" {
+			continue
+		}
+
+		k, v, _ := strings.Cut(t, "=")
+		if !strings.EqualFold(strings.TrimSpace(k), "url") {
+			continue
+		}
+		v = strings.Trim(strings.TrimSpace(v), `"`)
+		remotes = append(remotes, GitRemote{remote, v})
+	}
+	return remotes, scanner.Err()
+}
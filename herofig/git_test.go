@@ -0,0 +1,110 @@
+package herofig_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestHerokuApp(t *testing.T) {
+	cases := []struct {
+		url string
+		app string
+		ok  bool
+	}{
+		{"https://git.heroku.com/my-app.git", "my-app", true},
+		{"git@heroku.com:my-app.git", "my-app", true},
+		{"ssh://git@heroku.com/my-app.git", "my-app", true},
+		{"git@github.com:kayex/herofig.git", "", false},
+		{"https://git.heroku.com.example.com/my-app.git", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			app, ok := HerokuApp(c.url)
+			if app != c.app || ok != c.ok {
+				t.Errorf("HerokuApp(%s) = %s, %v; want %s, %v", c.url, app, ok, c.app, c.ok)
+			}
+		})
+	}
+}
+
+func writeGitConfig(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestInferApp(t *testing.T) {
+	single := `[core]
+	bare = false
+[remote "origin"]
+	url = git@github.com:company/api.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "staging"]
+	url = https://git.heroku.com/api-staging.git
+`
+	multiple := single + `[remote "production"]
+	url = git@heroku.com:api-production.git
+`
+
+	cases := []struct {
+		name    string
+		config  string
+		remote  string
+		want    string
+		wantErr bool
+	}{
+		{"single remote", single, "", "api-staging", false},
+		{"no heroku remotes", "[remote \"origin\"]\n\turl = git@github.com:company/api.git\n", "", "", false},
+		{"ambiguous", multiple, "", "", true},
+		{"selected remote", multiple, "production", "api-production", false},
+		{"heroku remote preferred", multiple + "[remote \"heroku\"]\n\turl = https://git.heroku.com/api.git\n", "", "api", false},
+		{"unknown remote", multiple, "development", "", true},
+		{"not a heroku remote", multiple, "origin", "", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := writeGitConfig(t, c.config)
+			nested := filepath.Join(dir, "sub")
+			if err := os.Mkdir(nested, 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			app, err := InferApp(nested, c.remote)
+			if (err != nil) != c.wantErr || app != c.want {
+				t.Errorf("InferApp(%s) = %q, %v; want %q, error %v", c.remote, app, err, c.want, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestInferApp_Worktree(t *testing.T) {
+	repo := writeGitConfig(t, "[remote \"heroku\"]\n\turl = https://git.heroku.com/api.git\n")
+	worktreeGitDir := filepath.Join(repo, ".git", "worktrees", "feature")
+	if err := os.MkdirAll(worktreeGitDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := InferApp(worktree, "")
+	if err != nil || app != "api" {
+		t.Errorf("InferApp() = %q, %v; want api", app, err)
+	}
+}
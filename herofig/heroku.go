@@ -0,0 +1,209 @@
+package herofig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Heroku is a client for the config of a Heroku application, which runs the Heroku CLI. The CLI must be installed
+// and logged in.
+type Heroku struct {
+	app   string
+	cache *ConfigCache
+	// account is the Heroku account, as of the last check of whether the user is authenticated.
+	account string
+}
+
+// NewHeroku returns a client for app. Its config cache is disabled, but is still invalidated by any changes.
+func NewHeroku(app string) *Heroku {
+	return &Heroku{app: app, cache: &ConfigCache{}}
+}
+
+// Cache returns the config cache of the application.
+func (h *Heroku) Cache() *ConfigCache {
+	return h.cache
+}
+
+func (h *Heroku) Config(ctx context.Context) (Config, error) {
+	cfg, err := h.cache.Get(h.app)
+	if err != nil || cfg != nil {
+		return cfg, err
+	}
+
+	res, err := h.run(ctx, "config", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = make(map[string]string)
+	err = json.Unmarshal(res, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling config JSON: %v", err)
+	}
+	// The cache is only an optimization, so failing to write it is not an error.
+	_ = h.cache.Put(h.app, cfg)
+	return cfg, nil
+}
+
+// ConfigValue returns the value of key, or an ErrNotFound error if it is not set.
+func (h *Heroku) ConfigValue(ctx context.Context, key string) (string, error) {
+	cfg, err := h.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	v, ok := cfg[key]
+	if !ok {
+		return "", Errorf(ErrNotFound, "%s is not set on %s", key, h.app)
+	}
+	return v, nil
+}
+
+func (h *Heroku) SetConfigValue(ctx context.Context, key, value string) error {
+	_, err := h.run(ctx, "config:set", Var{key, value}.String())
+	h.invalidateCache()
+	return err
+}
+
+func (h *Heroku) SetConfig(ctx context.Context, cfg Config) error {
+	var vars []string
+	for k, v := range cfg {
+		vars = append(vars, Var{k, v}.String())
+	}
+
+	_, err := h.run(ctx, "config:set", vars...)
+	h.invalidateCache()
+	return err
+}
+
+// Apps returns the names of all applications the user has access to.
+func (h *Heroku) Apps(ctx context.Context) ([]string, error) {
+	res, err := h.run(ctx, "apps", "--all", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []struct {
+		Name string `json:"name"`
+	}
+	err = json.Unmarshal(res, &apps)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling apps JSON: %v", err)
+	}
+
+	names := make([]string, len(apps))
+	for i, a := range apps {
+		names[i] = a.Name
+	}
+	return names, nil
+}
+
+func (h *Heroku) App() string {
+	return h.app
+}
+
+// invalidateCache removes the cached config after a change, even if the change failed, since it may have been
+// partially applied.
+func (h *Heroku) invalidateCache() {
+	if err := h.cache.Invalidate(h.app); err != nil {
+		h.cache.warn("Could not remove the cached config of %s: %v", h.app, err)
+	}
+}
+
+func (h *Heroku) run(ctx context.Context, script string, args ...string) ([]byte, error) {
+	if h.cache.Offline {
+		return nil, fmt.Errorf("cannot run heroku %s while offline", script)
+	}
+	args = append([]string{script}, args...)
+	if h.app != "" {
+		args = append(args, "--app", h.app)
+	}
+
+	cmd := exec.CommandContext(ctx, "heroku", args...)
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, herokuError(err)
+	}
+	return stdout, err
+}
+
+// Authenticated reports whether the user is logged into the Heroku CLI.
+func (h *Heroku) Authenticated(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, "heroku", "whoami")
+	out, err := cmd.Output()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() == 100 {
+		return false, nil
+	}
+	if err != nil {
+		return false, herokuError(err)
+	}
+	h.account = strings.TrimSpace(string(out))
+	return true, nil
+}
+
+// Account returns the Heroku account the Heroku CLI is logged into.
+func (h *Heroku) Account(ctx context.Context) (string, error) {
+	if h.account == "" {
+		authenticated, err := h.Authenticated(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !authenticated {
+			return "", Errorf(ErrAuth, "not logged into the Heroku CLI")
+		}
+	}
+	return h.account, nil
+}
+
+// herokuErrorKinds maps messages printed by the Heroku CLI to the kind of error they describe.
+var herokuErrorKinds = []struct {
+	kind     ErrorKind
+	messages []string
+}{
+	{ErrAuth, []string{"HTTP Error 401", "Invalid credentials", "not logged in", "heroku login"}},
+	{ErrRateLimited, []string{"HTTP Error 429", "rate limit", "Too Many Requests"}},
+	{ErrPermission, []string{"HTTP Error 403", "do not have access", "don't have access", "Forbidden"}},
+	{ErrNotFound, []string{"HTTP Error 404", "Couldn't find that app", "not found"}},
+	{ErrConflict, []string{"HTTP Error 409", "Conflict"}},
+	{ErrValidation, []string{"HTTP Error 422", "Invalid"}},
+	{ErrNetwork, []string{"ENOTFOUND", "ECONNREFUSED", "ECONNRESET", "ETIMEDOUT", "EAI_AGAIN", "socket hang up"}},
+}
+
+// herokuError returns an error describing a failed Heroku CLI command, classified by the message it printed.
+func herokuError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("the Heroku CLI is not installed (https://devcenter.heroku.com/articles/heroku-cli): %w", err)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		return fmt.Errorf("Heroku CLI: %w", err)
+	}
+
+	message := herokuMessage(ee.Stderr)
+	err = fmt.Errorf("Heroku CLI (%w): %s", err, message)
+	for _, k := range herokuErrorKinds {
+		for _, m := range k.messages {
+			if strings.Contains(strings.ToLower(message), strings.ToLower(m)) {
+				return &Error{k.kind, err}
+			}
+		}
+	}
+	return err
+}
+
+// herokuMessage returns the error message printed by the Heroku CLI on stderr, without the arrows and indentation it
+// is decorated with.
+func herokuMessage(stderr []byte) string {
+	var lines []string
+	for _, l := range strings.Split(string(stderr), "\n") {
+		l = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "▸›"))
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, " ")
+}
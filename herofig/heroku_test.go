@@ -0,0 +1,48 @@
+package herofig
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestHerokuError(t *testing.T) {
+	cases := []struct {
+		stderr string
+		kind   ErrorKind
+	}{
+		{" ▸    Invalid credentials provided.\n ▸    Please run heroku login\n", ErrAuth},
+		{" ▸    Couldn't find that app.\n", ErrNotFound},
+		{" ▸    You do not have access to the app my-app.\n", ErrPermission},
+		{" ▸    HTTP Error 429: Your account reached the API rate limit\n", ErrRateLimited},
+		{" ▸    getaddrinfo ENOTFOUND api.heroku.com\n", ErrNetwork},
+		{" ▸    Something unexpected happened\n", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(strings.TrimSpace(c.stderr), func(t *testing.T) {
+			cmd := exec.Command("sh", "-c", `printf '%s' "$STDERR" >&2; exit 1`)
+			cmd.Env = append(os.Environ(), "STDERR="+c.stderr)
+			_, err := cmd.Output()
+			err = herokuError(err)
+			if k := Kind(err); k != c.kind {
+				t.Errorf("Kind(herokuError(%q)) = %q; want %q", c.stderr, k, c.kind)
+			}
+			if strings.HasSuffix(err.Error(), "\n") || strings.Contains(err.Error(), "▸") {
+				t.Errorf("herokuError(%q) = %q; want a single line without decorations", c.stderr, err)
+			}
+		})
+	}
+}
+
+func TestHeroku_Offline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	h := NewHeroku("my-app")
+	h.Cache().Offline = true
+	if err := h.SetConfigValue(context.Background(), "KEY", "value"); err == nil {
+		t.Error("SetConfigValue succeeded offline; want error")
+	}
+}
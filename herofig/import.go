@@ -0,0 +1,277 @@
+package herofig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kayex/herofig/internal/yaml"
+)
+
+// ComposeFormat reads the environment of a service in a docker-compose file, including its env_file entries.
+var ComposeFormat = Format{
+	Name:      "compose",
+	Filenames: []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"},
+	Decode:    decodeCompose,
+}
+
+// HerokuFormat reads and writes the flat JSON object printed by heroku config --json.
+var HerokuFormat = Format{
+	Name: "heroku",
+	Decode: func(r io.Reader, _ FormatOptions) (Config, error) {
+		cfg := make(Config)
+		if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("expected an object of string values: %v", err)
+		}
+		return cfg, nil
+	},
+	Encode: encodeJSON,
+}
+
+// decodeKubernetes reads the manifests of kind in r, like kubernetesConfig.
+func decodeKubernetes(r io.Reader, kind string, opts FormatOptions) (Config, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := yaml.DecodeAll(src)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetesConfig(docs, kind, opts)
+}
+
+// kubernetesKind returns the kind of the Secret or ConfigMap manifests in docs, or an empty string if docs are not
+// Kubernetes manifests. Manifests of other kinds, or of both kinds, are an error, since they would otherwise be
+// flattened into keys such as apiVersion and metadata_name.
+func kubernetesKind(docs []any) (string, error) {
+	isManifest := false
+	kinds := make(map[string]bool)
+	for _, doc := range docs {
+		m, _ := doc.(map[string]any)
+		if m["apiVersion"] == nil || m["kind"] == nil {
+			continue
+		}
+		isManifest = true
+		for _, manifest := range kubernetesManifests(doc) {
+			if kind := manifest["kind"]; kind == "Secret" || kind == "ConfigMap" {
+				kinds[kind.(string)] = true
+			}
+		}
+	}
+
+	switch {
+	case !isManifest:
+		return "", nil
+	case len(kinds) == 0:
+		return "", fmt.Errorf("no Secret or ConfigMap found in Kubernetes manifests")
+	case len(kinds) > 1:
+		return "", fmt.Errorf("found both Secrets and ConfigMaps, select one with --format secret or --format configmap")
+	}
+	for kind := range kinds {
+		return kind, nil
+	}
+	return "", nil
+}
+
+// kubernetesConfig reads the data of all manifests of kind in docs, optionally limited to manifests named
+// opts.Name. Base64-encoded Secret data is decoded, and stringData takes precedence over data as it does in
+// Kubernetes.
+func kubernetesConfig(docs []any, kind string, opts FormatOptions) (Config, error) {
+	cfg := make(Config)
+	found := false
+	for _, doc := range docs {
+		for _, manifest := range kubernetesManifests(doc) {
+			if manifest["kind"] != kind {
+				continue
+			}
+			metadata, _ := manifest["metadata"].(map[string]any)
+			if opts.Name != "" && metadata["name"] != opts.Name {
+				continue
+			}
+			found = true
+
+			data, err := stringMap(manifest["data"])
+			if err != nil {
+				return nil, fmt.Errorf("%s data: %v", kind, err)
+			}
+			for k, v := range data {
+				if kind == "Secret" {
+					decoded, err := base64.StdEncoding.DecodeString(v)
+					if err != nil {
+						return nil, fmt.Errorf("decoding %s: %v", k, err)
+					}
+					v = string(decoded)
+				}
+				cfg[k] = v
+			}
+
+			stringData, err := stringMap(manifest["stringData"])
+			if err != nil {
+				return nil, fmt.Errorf("%s stringData: %v", kind, err)
+			}
+			maps.Copy(cfg, stringData)
+		}
+	}
+
+	if !found {
+		if opts.Name != "" {
+			return nil, fmt.Errorf("no %s named %s found", kind, opts.Name)
+		}
+		return nil, fmt.Errorf("no %s found", kind)
+	}
+	return cfg, nil
+}
+
+// kubernetesManifests returns the manifests in doc, expanding the items of List manifests.
+func kubernetesManifests(doc any) []map[string]any {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if m["kind"] != "List" {
+		return []map[string]any{m}
+	}
+
+	items, _ := m["items"].([]any)
+	var manifests []map[string]any
+	for _, item := range items {
+		manifests = append(manifests, kubernetesManifests(item)...)
+	}
+	return manifests
+}
+
+func decodeCompose(r io.Reader, opts FormatOptions) (Config, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := yaml.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	root, _ := doc.(map[string]any)
+	services, _ := root["services"].(map[string]any)
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no services found")
+	}
+	names := slices.Sorted(maps.Keys(services))
+
+	name := opts.Service
+	if name == "" {
+		if len(services) > 1 {
+			return nil, fmt.Errorf("multiple services found, select one with --service: %s", strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	service, ok := services[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("service %s not found (available services: %s)", name, strings.Join(names, ", "))
+	}
+
+	// Like docker compose, quotes are removed from the values in env_file.
+	cfg := make(Config)
+	for _, envFile := range composeEnvFiles(service["env_file"]) {
+		path := envFile.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(opts.Dir, path)
+		}
+		fileCfg, err := LoadQuoted(path)
+		if err != nil {
+			if !envFile.required && isNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("loading env_file of service %s: %v", name, err)
+		}
+		maps.Copy(cfg, fileCfg)
+	}
+
+	// Values in environment take precedence over values from env_file.
+	switch env := service["environment"].(type) {
+	case map[string]any:
+		for k, v := range env {
+			if v == nil {
+				warnShellValue(opts, k)
+				continue
+			}
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported value for %s in environment of service %s", k, name)
+			}
+			cfg[k] = s
+		}
+	case []any:
+		for _, item := range env {
+			s, _ := item.(string)
+			k, v, found := strings.Cut(s, "=")
+			if !found {
+				warnShellValue(opts, k)
+				continue
+			}
+			cfg[k] = v
+		}
+	}
+	return cfg, nil
+}
+
+// warnShellValue warns that key is skipped because it has no value in the environment of a service, in which case
+// docker compose takes its value from the shell.
+func warnShellValue(opts FormatOptions, key string) {
+	opts.warn("Skipping %s: its value is taken from the shell environment when running docker compose", key)
+}
+
+type composeEnvFile struct {
+	path     string
+	required bool
+}
+
+// composeEnvFiles returns the files in an env_file entry, which may be a string or a list of strings or objects.
+func composeEnvFiles(v any) []composeEnvFile {
+	switch v := v.(type) {
+	case string:
+		return []composeEnvFile{{v, true}}
+	case []any:
+		var files []composeEnvFile
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				files = append(files, composeEnvFile{item, true})
+			case map[string]any:
+				path, _ := item["path"].(string)
+				files = append(files, composeEnvFile{path, item["required"] != "false"})
+			}
+		}
+		return files
+	}
+	return nil
+}
+
+func stringMap(v any) (map[string]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+
+	s := make(map[string]string, len(m))
+	for k, v := range m {
+		if v == nil {
+			s[k] = ""
+			continue
+		}
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("unsupported value for %s", k)
+		}
+		s[k] = str
+	}
+	return s, nil
+}
@@ -0,0 +1,119 @@
+package herofig_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestImportFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"manifests.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: api
+data:
+  KEY: dmFsdWU=
+  OVERRIDDEN: b2xk
+stringData:
+  OVERRIDDEN: new
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: worker
+data:
+  WORKER: dmFsdWU=
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: api
+data:
+  REGION: eu-north-1
+`,
+		"docker-compose.yml": `services:
+  web:
+    env_file:
+      - web.env
+      - path: optional.env
+        required: false
+    environment:
+      - A=compose
+      - FROM_SHELL
+  worker:
+    environment:
+      B: worker
+      EMPTY: ""
+      FROM_SHELL:
+`,
+		"k8s/secret.yaml":     "apiVersion: v1\nkind: Secret\nmetadata:\n  name: api\ndata:\n  KEY: dmFsdWU=\n",
+		"k8s/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n",
+		"web.env":             "A=file\nC=file\nQUOTED=\"bar\"\nSINGLE='a b'\n",
+		"heroku.json":         `{"DATABASE_URL": "postgres://localhost", "PORT": "5000"}`,
+	}
+	if err := os.Mkdir(filepath.Join(dir, "k8s"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		file   string
+		format Format
+		opts   FormatOptions
+		want   Config
+	}{
+		{"secret", "manifests.yaml", SecretFormat, FormatOptions{Name: "api"}, Config{"KEY": "value", "OVERRIDDEN": "new"}},
+		{"all secrets", "manifests.yaml", SecretFormat, FormatOptions{}, Config{"KEY": "value", "OVERRIDDEN": "new", "WORKER": "value"}},
+		{"configmap", "manifests.yaml", ConfigMapFormat, FormatOptions{}, Config{"REGION": "eu-north-1"}},
+		{"detected secret", "k8s/secret.yaml", DetectFormat("secret.yaml"), FormatOptions{}, Config{"KEY": "value"}},
+		{"compose", "docker-compose.yml", ComposeFormat, FormatOptions{Service: "web"}, Config{"A": "compose", "C": "file", "QUOTED": "bar", "SINGLE": "a b"}},
+		{"compose mapping", "docker-compose.yml", ComposeFormat, FormatOptions{Service: "worker"}, Config{"B": "worker", "EMPTY": ""}},
+		{"heroku", "heroku.json", HerokuFormat, FormatOptions{}, Config{"DATABASE_URL": "postgres://localhost", "PORT": "5000"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := LoadFormat(filepath.Join(dir, c.file), c.format, c.opts)
+			if err != nil {
+				t.Fatalf("LoadFormat(%s): %v", c.file, err)
+			}
+			if !maps.Equal(cfg, c.want) {
+				t.Errorf("LoadFormat(%s) = %v; want %v", c.file, cfg, c.want)
+			}
+		})
+	}
+
+	errors := []struct {
+		name   string
+		file   string
+		format Format
+	}{
+		{"ambiguous compose service", "docker-compose.yml", ComposeFormat},
+		{"secrets and configmaps as yaml", "manifests.yaml", DetectFormat("manifests.yaml")},
+		{"other manifests as yaml", "k8s/deployment.yaml", DetectFormat("deployment.yaml")},
+	}
+	for _, c := range errors {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := LoadFormat(filepath.Join(dir, c.file), c.format, FormatOptions{})
+			if err == nil {
+				t.Errorf("LoadFormat(%s) = %v; want error", c.file, cfg)
+			}
+		})
+	}
+
+	t.Run("detect compose", func(t *testing.T) {
+		if f := DetectFormat(filepath.Join(dir, "docker-compose.yml")); f.Name != ComposeFormat.Name {
+			t.Errorf("DetectFormat(docker-compose.yml) = %s; want %s", f.Name, ComposeFormat.Name)
+		}
+	})
+}
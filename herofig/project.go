@@ -0,0 +1,181 @@
+package herofig
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const ProjectFilename = ".herofig.json"
+
+// Project is a .herofig.json project file, which maps named environments to applications and env files.
+type Project struct {
+	// Dir is the directory containing the project file.
+	Dir          string                 `json:"-"`
+	Environments map[string]Environment `json:"environments"`
+}
+
+type Environment struct {
+	Name string `json:"-"`
+	App  string `json:"app"`
+	// Files are the env files of the environment, merged from left to right. Relative paths are resolved against
+	// the directory of the project file.
+	Files []string `json:"files"`
+	// Ignore are glob patterns of keys that are never pulled, pushed or compared.
+	Ignore     []string   `json:"ignore"`
+	Protection Protection `json:"protection"`
+
+	// IgnoreFile and Selector further restrict the keys of the environment. They are not part of the project file.
+	IgnoreFile *IgnoreFile `json:"-"`
+	Selector   Selector    `json:"-"`
+}
+
+// Protection controls whether commands are allowed to change the config of an environment.
+type Protection string
+
+const (
+	Unprotected Protection = ""
+	// ConfirmWrites requires confirmation before changing the config.
+	ConfirmWrites Protection = "confirm"
+	// ReadOnly refuses any changes to the config.
+	ReadOnly Protection = "readonly"
+)
+
+// FindProject looks for a project file in dir and its parent directories, returning nil if none is found.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, ProjectFilename))
+		if err == nil {
+			return parseProject(b, dir)
+		}
+		if !isNotExist(err) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func parseProject(b []byte, dir string) (*Project, error) {
+	p := Project{Dir: dir}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, Errorf(ErrValidation, "parsing %s: %v", filepath.Join(dir, ProjectFilename), err)
+	}
+
+	for name, env := range p.Environments {
+		switch env.Protection {
+		case Unprotected, ConfirmWrites, ReadOnly:
+		case "none":
+			env.Protection = Unprotected
+		default:
+			return nil, Errorf(ErrValidation, "environment %s: invalid protection %q (must be none, confirm or readonly)", name, env.Protection)
+		}
+		for _, pattern := range env.Ignore {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, Errorf(ErrValidation, "environment %s: invalid ignore pattern %q", name, pattern)
+			}
+		}
+
+		env.Name = name
+		if f, ok := strings.CutPrefix(env.App, FilePrefix); ok && f != "" && !filepath.IsAbs(f) {
+			env.App = FilePrefix + relativeToWorkingDir(filepath.Join(dir, f))
+		}
+		for i, f := range env.Files {
+			if !filepath.IsAbs(f) {
+				env.Files[i] = relativeToWorkingDir(filepath.Join(dir, f))
+			}
+		}
+		p.Environments[name] = env
+	}
+	return &p, nil
+}
+
+func (p *Project) Environment(name string) (Environment, error) {
+	env, ok := p.Environments[name]
+	if !ok {
+		var names []string
+		for n := range p.Environments {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return Environment{}, Errorf(ErrNotFound, "environment %s not found in %s (available environments: %s)", name, filepath.Join(p.Dir, ProjectFilename), strings.Join(names, ", "))
+	}
+	return env, nil
+}
+
+// Protection returns the strictest protection of the environments of app, which is Unprotected if app is not used by
+// any environment.
+func (p *Project) Protection(app string) Protection {
+	protection := Unprotected
+	for _, env := range p.Environments {
+		if !SameApp(env.App, app) {
+			continue
+		}
+		if env.Protection == ReadOnly || protection == Unprotected {
+			protection = env.Protection
+		}
+	}
+	return protection
+}
+
+// SameApp reports whether a and b are the same application, comparing file applications by their absolute paths.
+func SameApp(a, b string) bool {
+	return absApp(a) == absApp(b)
+}
+
+// absApp returns app with the path of a file application made absolute, so that it names the same application in
+// any working directory.
+func absApp(app string) string {
+	path, ok := strings.CutPrefix(app, FilePrefix)
+	if !ok {
+		return app
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return app
+	}
+	return FilePrefix + abs
+}
+
+// Ignored reports whether key matches any of the ignore patterns of e, is ignored by its ignore file, or is not
+// selected by its selector.
+func (e Environment) Ignored(key string) bool {
+	for _, pattern := range e.Ignore {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return e.IgnoreFile.Ignored(key) || !e.Selector.Match(key)
+}
+
+// Filter returns cfg without the keys ignored by e.
+func (e Environment) Filter(cfg Config) Config {
+	return cfg.Filter(func(key string) bool {
+		return !e.Ignored(key)
+	})
+}
+
+// relativeToWorkingDir returns path relative to the working directory if possible, for display purposes.
+func relativeToWorkingDir(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
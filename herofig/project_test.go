@@ -0,0 +1,117 @@
+package herofig_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestFindProject(t *testing.T) {
+	dir := t.TempDir()
+	project := `{
+	"environments": {
+		"production": {
+			"app": "api-production",
+			"files": ["base.env", "production.env"],
+			"ignore": ["HEROKU_*"],
+			"protection": "readonly"
+		},
+		"staging": {
+			"app": "api-staging"
+		},
+		"local": {
+			"app": "file:local.json"
+		}
+	}
+}`
+	if err := os.WriteFile(filepath.Join(dir, ProjectFilename), []byte(project), 0600); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := FindProject(nested)
+	if err != nil || p == nil {
+		t.Fatalf("FindProject(%s) = %v, %v; want project", nested, p, err)
+	}
+
+	env, err := p.Environment("production")
+	if err != nil {
+		t.Fatalf("Environment(production): %v", err)
+	}
+	if env.Name != "production" || env.App != "api-production" || env.Protection != ReadOnly {
+		t.Errorf("Environment(production) = %+v", env)
+	}
+	for i, want := range []string{"base.env", "production.env"} {
+		abs, _ := filepath.Abs(env.Files[i])
+		if abs != filepath.Join(dir, want) {
+			t.Errorf("Environment(production).Files[%d] = %s; want %s", i, env.Files[i], filepath.Join(dir, want))
+		}
+	}
+
+	local, err := p.Environment("local")
+	if err != nil {
+		t.Fatalf("Environment(local): %v", err)
+	}
+	if abs, _ := filepath.Abs(strings.TrimPrefix(local.App, FilePrefix)); abs != filepath.Join(dir, "local.json") {
+		t.Errorf("Environment(local).App = %s; want file:%s", local.App, filepath.Join(dir, "local.json"))
+	}
+
+	if _, err := p.Environment("development"); err == nil {
+		t.Errorf("Environment(development): want error")
+	}
+}
+
+func TestFindProject_NotFound(t *testing.T) {
+	p, err := FindProject(t.TempDir())
+	if err != nil || p != nil {
+		t.Errorf("FindProject() = %v, %v; want nil, nil", p, err)
+	}
+}
+
+func TestEnvironment_Filter(t *testing.T) {
+	env := Environment{Ignore: []string{"HEROKU_*", "DATABASE_URL"}}
+	cfg := Config{"HEROKU_APP_NAME": "app", "DATABASE_URL": "postgres://", "KEY": "value"}
+
+	got := env.Filter(cfg).Ordered()
+	want := []Var{{"KEY", "value"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Filter(%v) = %v; want %v", cfg, got, want)
+	}
+}
+
+func TestProject_Protection(t *testing.T) {
+	p := Project{Environments: map[string]Environment{
+		"dev":        {App: "api-dev"},
+		"staging":    {App: "api-staging", Protection: ConfirmWrites},
+		"production": {App: "api", Protection: ReadOnly},
+		"hotfix":     {App: "api", Protection: ConfirmWrites},
+		"local":      {App: "file:local.json", Protection: ConfirmWrites},
+	}}
+
+	cases := []struct {
+		app  string
+		want Protection
+	}{
+		{"api-dev", Unprotected},
+		{"api-staging", ConfirmWrites},
+		{"api", ReadOnly},
+		{"other", Unprotected},
+		{"file:./local.json", ConfirmWrites},
+		{"file:other.json", Unprotected},
+	}
+
+	for _, c := range cases {
+		t.Run(c.app, func(t *testing.T) {
+			if got := p.Protection(c.app); got != c.want {
+				t.Errorf("Protection(%s) = %q; want %q", c.app, got, c.want)
+			}
+		})
+	}
+}
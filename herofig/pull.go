@@ -0,0 +1,166 @@
+package herofig
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pullHeaderTag starts the header written into files by pull.
+const pullHeaderTag = "herofig:pulled"
+
+// PullRecord is the application a file was pulled from, and the hash of its config at the time.
+type PullRecord struct {
+	App  string
+	Hash string
+}
+
+// Header returns r as a header for FormatOptions.Header.
+func (r PullRecord) Header() string {
+	return fmt.Sprintf("%s hash=%s app=%s", pullHeaderTag, r.Hash, r.App)
+}
+
+// ReadPullRecord returns the record in the header of filename, or nil if it has none.
+func ReadPullRecord(filename string, f Format) (*PullRecord, error) {
+	if f.Comment == "" {
+		return nil, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+	return parsePullHeader(scanner.Text(), f), nil
+}
+
+func parsePullHeader(line string, f Format) *PullRecord {
+	fields, ok := strings.CutPrefix(strings.TrimSpace(line), f.Comment+" "+pullHeaderTag+" ")
+	if !ok {
+		return nil
+	}
+	hash, app, ok := strings.Cut(fields, " app=")
+	hash, hasHash := strings.CutPrefix(hash, "hash=")
+	if !ok || !hasHash || hash == "" || app == "" {
+		return nil
+	}
+	return &PullRecord{app, hash}
+}
+
+// WritePullRecord replaces the record in the header of filename with r, leaving the rest of the file unchanged.
+func WritePullRecord(filename string, f Format, r PullRecord) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	first, rest, _ := strings.Cut(string(b), "\n")
+	if parsePullHeader(first, f) == nil {
+		rest = string(b)
+	}
+	header := fmt.Sprintf("%s %s\n", f.Comment, r.Header())
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(header+rest), info.Mode().Perm())
+}
+
+// pullBase is the config of a file as of its last pull or push, as fingerprints of its values.
+type pullBase struct {
+	// File is the absolute path of the file.
+	File   string   `json:"file"`
+	Hash   string   `json:"hash"`
+	Config SyncBase `json:"config"`
+}
+
+// LoadPullBase returns the fingerprints of the config with hash as it was pulled into file, or nil if it was not
+// pulled on this machine.
+func LoadPullBase(file, hash string) (SyncBase, error) {
+	path, _, err := pullBasePath(file)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var base pullBase
+	if err := json.Unmarshal(b, &base); err != nil {
+		return nil, Errorf(ErrValidation, "reading %s: %v", path, err)
+	}
+	if base.Hash != hash {
+		return nil, nil
+	}
+	return base.Config, nil
+}
+
+// SavePullBase stores the fingerprints of the config with hash as it was pulled into or pushed from file, to report
+// what changed since. It replaces the config stored for file before, and removes those of files that no longer exist.
+func SavePullBase(file, hash string, config SyncBase) error {
+	path, abs, err := pullBasePath(file)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(pullBase{abs, hash, config})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return err
+	}
+	prunePullBases(filepath.Dir(path))
+	return nil
+}
+
+// prunePullBases removes the pull bases in dir of files that no longer exist, and those without a file that were kept
+// for every pulled config by earlier versions. Bases that cannot be read are left alone, since pruning is best effort.
+func prunePullBases(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var base pullBase
+		if err := json.Unmarshal(b, &base); err == nil && base.File != "" {
+			if _, err := os.Stat(base.File); !isNotExist(err) {
+				continue
+			}
+		}
+		os.Remove(path)
+	}
+}
+
+// pullBasePath returns the path of the pull base of file in the user state directory, and the absolute path of file.
+func pullBasePath(file string) (path, abs string, err error) {
+	abs, err = filepath.Abs(file)
+	if err != nil {
+		return "", "", err
+	}
+	dir, err := StateDir("pulls")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), abs, nil
+}
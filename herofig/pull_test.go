@@ -0,0 +1,123 @@
+package herofig_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestPullRecord(t *testing.T) {
+	cfg := Config{"A": "1", "B": "two words"}
+	record := PullRecord{App: "file:./my app.json", Hash: "ab12"}
+
+	for _, name := range []string{"app.env", "app.yaml", "app.toml", "app.json"} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), name)
+			format := DetectFormat(file)
+			if err := SaveFormat(file, cfg, format, FormatOptions{Separator: "_", Header: record.Header()}); err != nil {
+				t.Fatal(err)
+			}
+			if loaded, err := LoadFormat(file, format, FormatOptions{Separator: "_"}); err != nil || !reflect.DeepEqual(loaded, cfg) {
+				t.Errorf("LoadFormat() = %v, %v; want %v", loaded, err, cfg)
+			}
+
+			got, err := ReadPullRecord(file, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format.Comment == "" {
+				if got != nil {
+					t.Errorf("ReadPullRecord() = %+v; want nil for format without comments", got)
+				}
+				return
+			}
+			if got == nil || *got != record {
+				t.Errorf("ReadPullRecord() = %+v; want %+v", got, record)
+			}
+
+			updated := PullRecord{App: record.App, Hash: "cd34"}
+			if err := WritePullRecord(file, format, updated); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ReadPullRecord(file, format); err != nil || got == nil || *got != updated {
+				t.Errorf("ReadPullRecord() after WritePullRecord = %+v, %v; want %+v", got, err, updated)
+			}
+			if loaded, err := LoadFormat(file, format, FormatOptions{Separator: "_"}); err != nil || !reflect.DeepEqual(loaded, cfg) {
+				t.Errorf("LoadFormat() after WritePullRecord = %v, %v; want %v", loaded, err, cfg)
+			}
+		})
+	}
+}
+
+func TestWritePullRecord_NoHeader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(file, []byte("# Comment\nA=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := ReadPullRecord(file, EnvFormat); err != nil || r != nil {
+		t.Fatalf("ReadPullRecord() = %+v, %v; want nil", r, err)
+	}
+
+	r := PullRecord{App: "my-app", Hash: "ab12"}
+	if err := WritePullRecord(file, EnvFormat, r); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(file)
+	if want := "# " + r.Header() + "\n# Comment\nA=1\n"; string(b) != want {
+		t.Errorf("file = %q; want %q", b, want)
+	}
+	if !strings.HasPrefix(r.Header(), "herofig:pulled ") {
+		t.Errorf("Header() = %q", r.Header())
+	}
+}
+
+func TestPullBase(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.env"), filepath.Join(dir, "b.env")
+	for _, file := range []string{a, b} {
+		if err := os.WriteFile(file, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A base kept for every pulled config by earlier versions.
+	pulls := filepath.Join(state, "herofig", "pulls")
+	if err := os.MkdirAll(pulls, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pulls, "ab12.json"), []byte(`{"A":"6b86b273ff34fce1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	base := NewSyncBase(Config{"A": "1"})
+	if err := SavePullBase(a, "ab12", base); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePullBase(b, "ab12", base); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePullBase(a, "cd34", NewSyncBase(Config{"A": "2"})); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadPullBase(a, "ab12"); err != nil || got != nil {
+		t.Errorf("LoadPullBase() of a replaced base = %v, %v; want nil", got, err)
+	}
+	if got, err := LoadPullBase(b, "ab12"); err != nil || !reflect.DeepEqual(got, base) {
+		t.Errorf("LoadPullBase() = %v, %v; want %v", got, err, base)
+	}
+
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePullBase(a, "ef56", base); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(pulls); err != nil || len(entries) != 1 {
+		t.Errorf("pull bases = %v, %v; want only the base of a.env", entries, err)
+	}
+}
@@ -0,0 +1,150 @@
+package herofig
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFilename is the name of the file with patterns of keys that are always ignored.
+const IgnoreFilename = ".herofigignore"
+
+// Pattern matches config keys. Patterns are globs such as HEROKU_*, regular expressions prefixed with re:, or
+// prefixes of keys prefixed with prefix:.
+type Pattern struct {
+	glob   string
+	prefix string
+	re     *regexp.Regexp
+}
+
+func ParsePattern(s string) (Pattern, error) {
+	if prefix, ok := strings.CutPrefix(s, "prefix:"); ok {
+		return Pattern{prefix: prefix}, nil
+	}
+	if expr, ok := strings.CutPrefix(s, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return Pattern{}, Errorf(ErrValidation, "invalid pattern %q: %v", s, err)
+		}
+		return Pattern{re: re}, nil
+	}
+	if _, err := path.Match(s, ""); err != nil {
+		return Pattern{}, Errorf(ErrValidation, "invalid pattern %q: %v", s, err)
+	}
+	return Pattern{glob: s}, nil
+}
+
+// ParsePatterns parses each of patterns using ParsePattern.
+func ParsePatterns(patterns []string) ([]Pattern, error) {
+	parsed := make([]Pattern, len(patterns))
+	for i, s := range patterns {
+		p, err := ParsePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = p
+	}
+	return parsed, nil
+}
+
+func (p Pattern) Match(key string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(key)
+	case p.glob != "":
+		ok, _ := path.Match(p.glob, key)
+		return ok
+	}
+	return strings.HasPrefix(key, p.prefix)
+}
+
+func matchAny(patterns []Pattern, key string) bool {
+	for _, p := range patterns {
+		if p.Match(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selector selects the keys that match any of Only, or all keys if Only is empty, and none of Except.
+type Selector struct {
+	Only   []Pattern
+	Except []Pattern
+}
+
+func (s Selector) Match(key string) bool {
+	return (len(s.Only) == 0 || matchAny(s.Only, key)) && !matchAny(s.Except, key)
+}
+
+// IgnoreFile is a list of patterns of keys to ignore, with gitignore-style negation: a pattern prefixed with ! stops
+// ignoring keys that were ignored by an earlier pattern.
+type IgnoreFile struct {
+	Path  string
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern Pattern
+	negated bool
+}
+
+// ParseIgnoreFile parses the contents of an ignore file, with one pattern per line. Blank lines and lines starting
+// with # are skipped.
+func ParseIgnoreFile(path, content string) (*IgnoreFile, error) {
+	f := &IgnoreFile{Path: path}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		t := strings.TrimSpace(scanner.Text())
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		t, negated := strings.CutPrefix(t, "!")
+		p, err := ParsePattern(t)
+		if err != nil {
+			return nil, Errorf(ErrValidation, "%s line %d: %v", path, line, err)
+		}
+		f.rules = append(f.rules, ignoreRule{p, negated})
+	}
+	return f, nil
+}
+
+// FindIgnoreFile looks for an ignore file in dir and each of its parents, returning nil if none is found.
+func FindIgnoreFile(dir string) (*IgnoreFile, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, IgnoreFilename)
+		b, err := os.ReadFile(path)
+		if err == nil {
+			return ParseIgnoreFile(relativeToWorkingDir(path), string(b))
+		}
+		if !isNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Ignored reports whether key is ignored by the last pattern of f that matches it.
+func (f *IgnoreFile) Ignored(key string) bool {
+	if f == nil {
+		return false
+	}
+	ignored := false
+	for _, r := range f.rules {
+		if r.pattern.Match(key) {
+			ignored = !r.negated
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,157 @@
+package herofig_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestParsePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"HEROKU_*", "HEROKU_DYNO_ID", true},
+		{"HEROKU_*", "DATABASE_URL", false},
+		{"DATABASE_URL", "DATABASE_URL", true},
+		{"prefix:AWS_", "AWS_S3_BUCKET", true},
+		{"prefix:AWS_", "MY_AWS_KEY", false},
+		{"prefix:", "ANYTHING", true},
+		{"re:_URL$", "REDIS_URL", true},
+		{"re:_URL$", "REDIS_URL_2", false},
+		{"re:^(PORT|HOST)$", "HOST", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern+" "+c.key, func(t *testing.T) {
+			p, err := ParsePattern(c.pattern)
+			if err != nil {
+				t.Fatalf("ParsePattern(%q): %v", c.pattern, err)
+			}
+			if got := p.Match(c.key); got != c.want {
+				t.Errorf("ParsePattern(%q).Match(%q) = %v; want %v", c.pattern, c.key, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParsePattern_Invalid(t *testing.T) {
+	for _, pattern := range []string{"re:[", "HEROKU_["} {
+		t.Run(pattern, func(t *testing.T) {
+			if _, err := ParsePattern(pattern); Kind(err) != ErrValidation {
+				t.Errorf("ParsePattern(%q) = %v; want validation error", pattern, err)
+			}
+		})
+	}
+}
+
+func TestEnvironment_Filter_Selector(t *testing.T) {
+	cfg := Config{"HEROKU_DYNO_ID": "dyno", "AWS_KEY": "key", "AWS_SECRET": "secret", "PORT": "80"}
+	ignore, err := ParseIgnoreFile(IgnoreFilename, "# runtime metadata\nHEROKU_*\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		only   []string
+		except []string
+		want   []string
+	}{
+		{"all", nil, nil, []string{"AWS_KEY", "AWS_SECRET", "PORT"}},
+		{"only", []string{"prefix:AWS_"}, nil, []string{"AWS_KEY", "AWS_SECRET"}},
+		{"except", nil, []string{"re:SECRET"}, []string{"AWS_KEY", "PORT"}},
+		{"only and except", []string{"AWS_*", "PORT"}, []string{"AWS_KEY"}, []string{"AWS_SECRET", "PORT"}},
+		{"ignored keys are never selected", []string{"HEROKU_*"}, nil, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			only, err := ParsePatterns(c.only)
+			if err != nil {
+				t.Fatal(err)
+			}
+			except, err := ParsePatterns(c.except)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := Environment{IgnoreFile: ignore, Selector: Selector{Only: only, Except: except}}
+
+			var got []string
+			for _, v := range env.Filter(cfg).Ordered() {
+				got = append(got, v.Key)
+			}
+			if !slices.Equal(got, c.want) {
+				t.Errorf("Filter() = %v; want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreFile_Ignored(t *testing.T) {
+	f, err := ParseIgnoreFile(IgnoreFilename, `
+# Runtime dyno metadata
+HEROKU_*
+!HEROKU_APP_NAME
+
+re:^(REDIS|DATABASE)_URL$
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		key  string
+		want bool
+	}{
+		{"HEROKU_DYNO_ID", true},
+		{"HEROKU_APP_NAME", false},
+		{"DATABASE_URL", true},
+		{"REDIS_URL", true},
+		{"REDIS_TLS_URL", false},
+		{"PORT", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			if got := f.Ignored(c.key); got != c.want {
+				t.Errorf("Ignored(%q) = %v; want %v", c.key, got, c.want)
+			}
+		})
+	}
+}
+
+func TestFindIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := FindIgnoreFile(sub)
+	if err != nil || f != nil {
+		t.Fatalf("FindIgnoreFile() without file = %v, %v; want nil, nil", f, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFilename), []byte("HEROKU_*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = FindIgnoreFile(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Ignored("HEROKU_DYNO_ID") || f.Ignored("PORT") {
+		t.Errorf("FindIgnoreFile() did not load %s", filepath.Join(dir, IgnoreFilename))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFilename), []byte("re:(\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindIgnoreFile(sub); Kind(err) != ErrValidation {
+		t.Errorf("FindIgnoreFile() with invalid pattern = %v; want validation error", err)
+	}
+}
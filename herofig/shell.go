@@ -0,0 +1,93 @@
+package herofig
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var shellIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// shellFormat returns an encode-only format that writes each variable using line, after checking that its key is
+// a valid shell identifier.
+func shellFormat(name string, extensions []string, line func(v Var) string) Format {
+	return Format{
+		Name:       name,
+		Extensions: extensions,
+		Encode: func(w io.Writer, cfg Config, _ FormatOptions) error {
+			for _, v := range cfg.Ordered() {
+				if !shellIdentifier.MatchString(v.Key) {
+					return fmt.Errorf("%s cannot be exported as %s: not a valid variable name", v.Key, name)
+				}
+				if _, err := fmt.Fprintln(w, line(v)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+var ShFormat = shellFormat("sh", []string{".sh"}, func(v Var) string {
+	return fmt.Sprintf("export %s=%s", v.Key, shQuote(v.Value))
+})
+
+var DirenvFormat = shellFormat("direnv", []string{".envrc"}, func(v Var) string {
+	return fmt.Sprintf("export %s=%s", v.Key, shQuote(v.Value))
+})
+
+var FishFormat = shellFormat("fish", []string{".fish"}, func(v Var) string {
+	return fmt.Sprintf("set -gx %s %s", v.Key, fishQuote(v.Value))
+})
+
+var PowerShellFormat = shellFormat("powershell", []string{".ps1"}, func(v Var) string {
+	return fmt.Sprintf("$env:%s = %s", v.Key, powerShellQuote(v.Value))
+})
+
+// GitHubEnvFormat writes variables in the syntax of the $GITHUB_ENV file in GitHub Actions, using a heredoc
+// delimiter for multiline values.
+var GitHubEnvFormat = shellFormat("github", nil, func(v Var) string {
+	if !strings.ContainsAny(v.Value, "\r\n") {
+		return v.String()
+	}
+	return fmt.Sprintf("%s<<%s\n%s\n%s", v.Key, heredocDelimiter(v.Value), v.Value, heredocDelimiter(v.Value))
+})
+
+// shQuote returns s as a single-quoted POSIX shell word. Single quotes cannot be escaped inside single quotes, so
+// each one ends the quoted string, adds an escaped quote and starts a new quoted string.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+// powerShellQuote returns s as a single-quoted PowerShell string. PowerShell also ends single-quoted strings at the
+// typographic single quotes, so each of them is doubled like the ASCII quote.
+func powerShellQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\u2018', '\u2019', '\u201a', '\u201b':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// heredocDelimiter returns a delimiter that does not occur as a line in s.
+func heredocDelimiter(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	delimiter := "EOF"
+	for i := 1; slices.Contains(lines, delimiter); i++ {
+		delimiter = fmt.Sprintf("EOF_%d", i)
+	}
+	return delimiter
+}
@@ -0,0 +1,96 @@
+package herofig_test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestShFormat(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	values := []string{
+		"value",
+		"some value",
+		`it's "quoted"`,
+		"line 1\nline 2",
+		"$HOME `whoami` $(whoami) \\",
+	}
+
+	for _, v := range values {
+		t.Run(v, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ShFormat.Encode(&buf, Config{"KEY": v}, FormatOptions{}); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			out, err := exec.Command(sh, "-c", buf.String()+`printf '%s' "$KEY"`).Output()
+			if err != nil {
+				t.Fatalf("evaluating %q: %v", buf.String(), err)
+			}
+			if string(out) != v {
+				t.Errorf("evaluating %q: KEY = %q; want %q", buf.String(), out, v)
+			}
+		})
+	}
+}
+
+func TestShellFormats(t *testing.T) {
+	cfg := Config{"A": "it's", "B": "line 1\nline 2"}
+	cases := []struct {
+		format Format
+		want   string
+	}{
+		{FishFormat, "set -gx A 'it\\'s'\nset -gx B 'line 1\nline 2'\n"},
+		{PowerShellFormat, "$env:A = 'it''s'\n$env:B = 'line 1\nline 2'\n"},
+		{GitHubEnvFormat, "A=it's\nB<<EOF\nline 1\nline 2\nEOF\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.format.Encode(&buf, cfg, FormatOptions{}); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if buf.String() != c.want {
+				t.Errorf("Encode(%v) = %q; want %q", cfg, buf.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestPowerShellFormat(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"it's", "$env:KEY = 'it''s'\n"},
+		{"\u2018smart\u2019 quotes", "$env:KEY = '\u2018\u2018smart\u2019\u2019 quotes'\n"},
+		{"\u201a'; Remove-Item -Recurse ~; '\u201b", "$env:KEY = '\u201a\u201a''; Remove-Item -Recurse ~; ''\u201b\u201b'\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := PowerShellFormat.Encode(&buf, Config{"KEY": c.value}, FormatOptions{}); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if buf.String() != c.want {
+				t.Errorf("Encode(%q) = %q; want %q", c.value, buf.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestShellFormats_InvalidKey(t *testing.T) {
+	var buf bytes.Buffer
+	err := ShFormat.Encode(&buf, Config{"NOT-VALID": "value"}, FormatOptions{})
+	if err == nil {
+		t.Errorf("Encode(NOT-VALID) = %q; want error", buf.String())
+	}
+}
@@ -0,0 +1,171 @@
+package herofig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// SyncBase is the config of a file and an application as of their last sync, as fingerprints of its values by key,
+// so that no secrets are stored.
+type SyncBase map[string]string
+
+func NewSyncBase(cfg Config) SyncBase {
+	base := make(SyncBase, len(cfg))
+	for k, v := range cfg {
+		base[k] = Fingerprint(v)
+	}
+	return base
+}
+
+// Conflict is a variable that was changed differently in both configs of a three-way merge.
+type Conflict struct {
+	Key string
+	// KeepLocal is the change to the remote config that resolves the conflict by keeping the local value, and
+	// KeepRemote is the change to the local config that keeps the remote value.
+	KeepLocal  Change
+	KeepRemote Change
+}
+
+// SyncResult is the result of a three-way merge.
+type SyncResult struct {
+	// Local are the changes to apply to the local config, and Remote the changes to apply to the remote config.
+	Local  []Change
+	Remote []Change
+	// Conflicts are the variables that were changed differently in both configs. They are not included in Local or
+	// Remote.
+	Conflicts []Conflict
+}
+
+// ThreeWayMerge merges the changes made to local and remote since base. Variables changed in only one of them are
+// changed in the other, and variables changed differently in both are conflicts. Without a base, variables set in
+// only one of the configs are added to the other.
+func ThreeWayMerge(base SyncBase, local, remote Config) SyncResult {
+	var r SyncResult
+	keys := make(Config)
+	for k := range base {
+		keys[k] = ""
+	}
+	for _, v := range Merge(keys, local, remote).Ordered() {
+		k := v.Key
+		localChanged := base.changed(k, local)
+		remoteChanged := base.changed(k, remote)
+		lv, inLocal := local[k]
+		rv, inRemote := remote[k]
+		keepLocal := change(k, rv, inRemote, lv, inLocal)
+		keepRemote := change(k, lv, inLocal, rv, inRemote)
+
+		switch {
+		case !localChanged && !remoteChanged:
+		case localChanged && !remoteChanged:
+			r.Remote = append(r.Remote, keepLocal)
+		case !localChanged && remoteChanged:
+			r.Local = append(r.Local, keepRemote)
+		case inLocal == inRemote && lv == rv:
+			// Both configs were changed the same way.
+		default:
+			r.Conflicts = append(r.Conflicts, Conflict{k, keepLocal, keepRemote})
+		}
+	}
+	return r
+}
+
+// Changes returns the changes made to cfg since b, ordered by key. The old values are unknown, and left empty.
+func (b SyncBase) Changes(cfg Config) []Change {
+	keys := make(Config)
+	for k := range b {
+		keys[k] = ""
+	}
+	var changes []Change
+	for _, v := range Merge(keys, cfg).Ordered() {
+		if !b.changed(v.Key, cfg) {
+			continue
+		}
+		_, inBase := b[v.Key]
+		value, inCfg := cfg[v.Key]
+		switch {
+		case !inCfg:
+			changes = append(changes, Change{Kind: Removed, Key: v.Key})
+		case !inBase:
+			changes = append(changes, Change{Kind: Added, Key: v.Key, New: value})
+		default:
+			changes = append(changes, Change{Kind: Changed, Key: v.Key, New: value})
+		}
+	}
+	return changes
+}
+
+// changed reports whether key was changed in cfg since b.
+func (b SyncBase) changed(key string, cfg Config) bool {
+	fp, inBase := b[key]
+	v, inCfg := cfg[key]
+	return inBase != inCfg || inBase && Fingerprint(v) != fp
+}
+
+// change returns the Change that turns the value of key from old into updated.
+func change(key, old string, hasOld bool, updated string, hasUpdated bool) Change {
+	switch {
+	case !hasUpdated:
+		return Change{Removed, key, old, ""}
+	case !hasOld:
+		return Change{Added, key, "", updated}
+	}
+	return Change{Changed, key, old, updated}
+}
+
+// LoadSyncBase returns the base of the last sync of file and app, or nil if they have not been synced.
+func LoadSyncBase(file, app string) (SyncBase, error) {
+	path, err := syncBasePath(file, app)
+	if err != nil {
+		return nil, err
+	}
+	return readBase(path)
+}
+
+// SaveSyncBase stores the base of the sync of file and app.
+func SaveSyncBase(file, app string, base SyncBase) error {
+	path, err := syncBasePath(file, app)
+	if err != nil {
+		return err
+	}
+	return writeBase(path, base)
+}
+
+func readBase(path string) (SyncBase, error) {
+	b, err := os.ReadFile(path)
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var base SyncBase
+	if err := json.Unmarshal(b, &base); err != nil {
+		return nil, Errorf(ErrValidation, "reading %s: %v", path, err)
+	}
+	return base, nil
+}
+
+func writeBase(path string, base SyncBase) error {
+	b, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// syncBasePath returns the path of the sync base of file and app in the user state directory.
+func syncBasePath(file, app string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	dir, err := StateDir("sync")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs + "\x00" + app))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
@@ -0,0 +1,77 @@
+package herofig_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := NewSyncBase(Config{"SAME": "1", "LOCAL": "1", "REMOTE": "1", "BOTH": "1", "BOTH_SAME": "1", "GONE_LOCAL": "1", "GONE_REMOTE": "1"})
+	local := Config{"SAME": "1", "LOCAL": "2", "REMOTE": "1", "BOTH": "2", "BOTH_SAME": "2", "GONE_REMOTE": "1", "NEW_LOCAL": "1"}
+	remote := Config{"SAME": "1", "LOCAL": "1", "REMOTE": "2", "BOTH": "3", "BOTH_SAME": "2", "GONE_LOCAL": "1", "NEW_REMOTE": "1"}
+
+	got := ThreeWayMerge(base, local, remote)
+	want := SyncResult{
+		Local: []Change{
+			{Removed, "GONE_REMOTE", "1", ""},
+			{Added, "NEW_REMOTE", "", "1"},
+			{Changed, "REMOTE", "1", "2"},
+		},
+		Remote: []Change{
+			{Removed, "GONE_LOCAL", "1", ""},
+			{Changed, "LOCAL", "1", "2"},
+			{Added, "NEW_LOCAL", "", "1"},
+		},
+		Conflicts: []Conflict{
+			{"BOTH", Change{Changed, "BOTH", "3", "2"}, Change{Changed, "BOTH", "2", "3"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ThreeWayMerge() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestThreeWayMerge_NoBase(t *testing.T) {
+	got := ThreeWayMerge(nil, Config{"A": "1", "B": "1"}, Config{"B": "2", "C": "1"})
+	want := SyncResult{
+		Local:     []Change{{Added, "C", "", "1"}},
+		Remote:    []Change{{Added, "A", "", "1"}},
+		Conflicts: []Conflict{{"B", Change{Changed, "B", "2", "1"}, Change{Changed, "B", "1", "2"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ThreeWayMerge() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSyncBase(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if base, err := LoadSyncBase("app.env", "my-app"); err != nil || base != nil {
+		t.Fatalf("LoadSyncBase() = %v, %v; want nil for unsynced file", base, err)
+	}
+	want := NewSyncBase(Config{"A": "secret"})
+	if err := SaveSyncBase("app.env", "my-app", want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadSyncBase("app.env", "my-app"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadSyncBase() = %v, %v; want %v", got, err, want)
+	}
+	if got, err := LoadSyncBase("app.env", "other-app"); err != nil || got != nil {
+		t.Errorf("LoadSyncBase(other-app) = %v, %v; want nil", got, err)
+	}
+}
+
+func TestSyncBase_Changes(t *testing.T) {
+	base := NewSyncBase(Config{"A": "1", "B": "1", "C": "1"})
+	got := base.Changes(Config{"A": "1", "B": "2", "D": "1"})
+	want := []Change{
+		{Kind: Changed, Key: "B", New: "2"},
+		{Kind: Removed, Key: "C"},
+		{Kind: Added, Key: "D", New: "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %v; want %v", got, want)
+	}
+}
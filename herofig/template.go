@@ -0,0 +1,83 @@
+package herofig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// TemplateMeta holds the metadata available to output templates through the app, hash and mnemonic functions.
+type TemplateMeta struct {
+	App string
+	// Config returns the full config of App. It is only called if the template uses the hash or mnemonic functions.
+	Config func() (Config, error)
+}
+
+// NewTemplate parses an output template, which is executed with a []Var as its data.
+func NewTemplate(text string, meta TemplateMeta) (*template.Template, error) {
+	var cfg Config
+	config := func() (Config, error) {
+		if cfg != nil {
+			return cfg, nil
+		}
+		var err error
+		cfg, err = meta.Config()
+		return cfg, err
+	}
+
+	funcs := template.FuncMap{
+		"app": func() string {
+			return meta.App
+		},
+		"hash": func() (string, error) {
+			cfg, err := config()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%x", cfg.Hash()), nil
+		},
+		"mnemonic": func() (string, error) {
+			cfg, err := config()
+			if err != nil {
+				return "", err
+			}
+			return cfg.Hash().Mnemonic(2), nil
+		},
+		"quote": strconv.Quote,
+		"base64": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"mask":  Mask,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+
+	return template.New("output").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// ExecuteTemplate renders vars using t.
+func ExecuteTemplate(w io.Writer, t *template.Template, vars []Var) error {
+	if vars == nil {
+		vars = []Var{}
+	}
+	return t.Execute(w, vars)
+}
+
+// Mask hides a secret value, revealing only its last four characters if it is long enough for them not to give
+// away a meaningful part of the value.
+func Mask(s string) string {
+	if utf8.RuneCountInString(s) < 12 {
+		return "****"
+	}
+	r := []rune(s)
+	return "****" + string(r[len(r)-4:])
+}
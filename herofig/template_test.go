@@ -0,0 +1,48 @@
+package herofig_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/kayex/herofig/herofig"
+)
+
+func TestNewTemplate(t *testing.T) {
+	cfg := Config{"KEY": "value", "SECRET": "supersecretvalue"}
+	meta := TemplateMeta{
+		App: "my-app",
+		Config: func() (Config, error) {
+			return cfg, nil
+		},
+	}
+
+	cases := []struct {
+		template string
+		want     string
+	}{
+		{`{{range .}}{{.Key}}: {{.Value}}{{"\n"}}{{end}}`, "KEY: value\nSECRET: supersecretvalue\n"},
+		{`{{range .}}{{.Key | upper}}={{.Value | quote}};{{end}}`, `KEY="value";SECRET="supersecretvalue";`},
+		{`{{range .}}{{.Value | base64}} {{end}}`, "dmFsdWU= c3VwZXJzZWNyZXR2YWx1ZQ== "},
+		{`{{range .}}{{mask .Value}} {{end}}`, "**** ****alue "},
+		{`{{json .}}`, `[{"Key":"KEY","Value":"value"},{"Key":"SECRET","Value":"supersecretvalue"}]`},
+		{`{{app}} {{mnemonic}} {{hash}}`, fmt.Sprintf("my-app %s %x", cfg.Hash().Mnemonic(2), cfg.Hash())},
+	}
+
+	for _, c := range cases {
+		t.Run(c.template, func(t *testing.T) {
+			tmpl, err := NewTemplate(c.template, meta)
+			if err != nil {
+				t.Fatalf("NewTemplate(%s): %v", c.template, err)
+			}
+
+			var buf bytes.Buffer
+			if err := ExecuteTemplate(&buf, tmpl, cfg.Ordered()); err != nil {
+				t.Fatalf("ExecuteTemplate(%s): %v", c.template, err)
+			}
+			if buf.String() != c.want {
+				t.Errorf("ExecuteTemplate(%s) = %s; want %s", c.template, buf.String(), c.want)
+			}
+		})
+	}
+}
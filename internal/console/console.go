@@ -2,10 +2,15 @@ package console
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 var Success = c(color.FgGreen)
@@ -18,42 +23,157 @@ var App = c(color.FgMagenta)
 var FilePath = c(color.FgCyan)
 var ID = c(color.FgGreen)
 
+// Output is where status and progress messages meant for humans are printed. It is stderr, so that the output of
+// commands can be piped or redirected to a file without them.
+var Output io.Writer = os.Stderr
+
+// jsonErrors makes fatal errors print a JSON document on stdout.
+var jsonErrors bool
+
+// SetJSON switches between human-readable and JSON output. With JSON output, messages are printed without colors,
+// and fatal errors are printed as a JSON document on stdout.
+func SetJSON(enabled bool) {
+	jsonErrors = enabled
+	if enabled {
+		color.NoColor = true
+	}
+}
+
 func c(a ...color.Attribute) func(format string, a ...interface{}) string {
 	return color.New(a...).SprintfFunc()
 }
 
-func Confirm(message, prompt string, def bool) bool {
-	fmt.Printf("%s ", Warning(message))
+// PromptMode controls how Confirm asks for confirmation.
+type PromptMode int
+
+const (
+	// PromptAuto asks for confirmation if stdin is a terminal, and fails otherwise.
+	PromptAuto PromptMode = iota
+	// PromptYes confirms without asking.
+	PromptYes
+	// PromptNever fails instead of asking for confirmation.
+	PromptNever
+)
+
+var Prompts = PromptAuto
+
+// SetColor sets whether output is colored: always, never, or auto to color output if stdout is a terminal and
+// NO_COLOR is not set.
+func SetColor(mode string) error {
+	switch mode {
+	case "auto", "":
+	case "always":
+		// JSON output is never colored.
+		color.NoColor = jsonErrors
+	case "never":
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid color mode %q (must be always, never or auto)", mode)
+	}
+	return nil
+}
+
+// ErrNotInteractive is returned by Confirm when confirmation is required, but the user cannot be asked.
+var ErrNotInteractive = errors.New("confirmation is required, but herofig is not running interactively. Pass --yes to confirm")
+
+// Confirm asks the user to confirm on stderr, so that prompts do not end up in output that is piped to other
+// programs. If the user cannot be asked, ErrNotInteractive is returned unless prompts are confirmed automatically.
+func Confirm(message, prompt string, def bool) (bool, error) {
+	switch {
+	case Prompts == PromptYes:
+		return true, nil
+	case Prompts == PromptNever || !IsTerminal(os.Stdin):
+		return false, fmt.Errorf("%s %w", message, ErrNotInteractive)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s ", Warning(message))
 
 	if def {
-		fmt.Print(Warning(fmt.Sprintf("%s [Y/n] ", prompt)))
+		fmt.Fprint(os.Stderr, Warning(fmt.Sprintf("%s [Y/n] ", prompt)))
 	} else {
-		fmt.Print(Warning(fmt.Sprintf("%s [y/N] ", prompt)))
+		fmt.Fprint(os.Stderr, Warning(fmt.Sprintf("%s [y/N] ", prompt)))
 	}
 
 	reader := bufio.NewReader(os.Stdin)
 	text, _ := reader.ReadString('\n')
+	text = strings.TrimSpace(text)
 
-	if text == "\n" {
-		return def
+	if text == "" {
+		return def, nil
 	}
-	return text == "y\n" || text == "Y\n"
+	return text == "y" || text == "Y", nil
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
 
-func ConfirmOverwrite(filename string) bool {
+func ConfirmOverwrite(filename string) (bool, error) {
 	if _, err := os.Stat(filename); err == nil {
 		return Confirm(fmt.Sprintf("%s already exists.", filename), "Overwrite?", false)
 	}
-	return true
+	return true, nil
+}
+
+// Warnf prints a warning to stderr, so that it does not end up in output that is piped to other programs.
+func Warnf(format string, v ...any) {
+	fmt.Fprintln(os.Stderr, Warning(format, v...))
+}
+
+// Printf prints a message meant for humans to Output.
+func Printf(format string, v ...any) {
+	fmt.Fprintf(Output, format, v...)
+}
+
+// Println prints a message meant for humans to Output.
+func Println(v ...any) {
+	fmt.Fprintln(Output, v...)
+}
+
+// Classify returns the error code and exit status of the first error passed to Fatalln or Fatalf.
+var Classify = func(err error) (code string, status int) {
+	return "error", 1
 }
 
 func Fatalln(v ...any) {
-	fmt.Println(v...)
-	os.Exit(1)
+	code, status := classify(v)
+	Fail(code, status, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
 }
 
 func Fatalf(format string, v ...any) {
-	fmt.Printf(format, v...)
-	fmt.Print("\n")
-	os.Exit(1)
+	code, status := classify(v)
+	Fail(code, status, fmt.Sprintf(format, v...))
+}
+
+func classify(v []any) (string, int) {
+	for _, a := range v {
+		if err, ok := a.(error); ok {
+			return Classify(err)
+		}
+	}
+	return "error", 1
+}
+
+// Fail prints message to stderr and exits with status. Any lines following the first line of message are printed
+// as hints, without the error color. The code identifies the kind of error in JSON output, where the error is
+// printed as a JSON document on stdout instead.
+func Fail(code string, status int, message string) {
+	if jsonErrors {
+		type details struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		b, _ := json.Marshal(struct {
+			Error details `json:"error"`
+		}{details{code, message}})
+		fmt.Println(string(b))
+	} else {
+		first, hints, _ := strings.Cut(message, "\n")
+		fmt.Fprintln(os.Stderr, Error("%s", first))
+		if hints != "" {
+			fmt.Fprintln(os.Stderr, hints)
+		}
+	}
+	os.Exit(status)
 }
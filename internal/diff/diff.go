@@ -0,0 +1,66 @@
+package diff
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the edits required to turn a into b, based on the longest common subsequence of lines.
+func Lines(a, b []string) []Edit {
+	// lcs[i][j] holds the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []Edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, Edit{Delete, a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, Edit{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Insert, b[j]})
+	}
+	return edits
+}
+
+// Changed reports whether edits contain any insertions or deletions.
+func Changed(edits []Edit) bool {
+	for _, e := range edits {
+		if e.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/internal/diff"
+)
+
+func TestLines(t *testing.T) {
+	cases := []struct {
+		name string
+		a    []string
+		b    []string
+		want []Edit
+	}{
+		{
+			"equal",
+			[]string{"A=1", "B=2"},
+			[]string{"A=1", "B=2"},
+			[]Edit{{Equal, "A=1"}, {Equal, "B=2"}},
+		},
+		{
+			"changed line",
+			[]string{"A=1", "B=2", "C=3"},
+			[]string{"A=1", "B=3", "C=3"},
+			[]Edit{{Equal, "A=1"}, {Delete, "B=2"}, {Insert, "B=3"}, {Equal, "C=3"}},
+		},
+		{
+			"appended",
+			[]string{"A=1"},
+			[]string{"A=1", "B=2"},
+			[]Edit{{Equal, "A=1"}, {Insert, "B=2"}},
+		},
+		{
+			"removed",
+			[]string{"A=1", "", "B=2"},
+			[]string{"A=1", "B=2"},
+			[]Edit{{Equal, "A=1"}, {Delete, ""}, {Equal, "B=2"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Lines(c.a, c.b)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Lines(%q, %q) = %v; want %v", c.a, c.b, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package toml decodes TOML documents into nested maps using github.com/BurntSushi/toml, and quotes keys and strings
+// for encoding.
+//
+// Decoded tables are map[string]any, arrays are []any and all other values are decoded as strings. Numbers, booleans
+// and dates are formatted in their canonical form, so that 1_000 is decoded as "1000".
+package toml
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func Decode(data []byte) (map[string]any, error) {
+	var m map[string]any
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		return nil, err
+	}
+	v, err := value(m)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+func value(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			decoded, err := value(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = decoded
+		}
+		return m, nil
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return value(items)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			decoded, err := value(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = decoded
+		}
+		return items, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return float(v), nil
+	case time.Time:
+		return datetime(v), nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
+
+func float(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// datetime formats t like TOML, without an offset for local dates and times, which the decoder marks with the
+// names of their locations.
+func datetime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Key returns k as a TOML key, quoting it if it cannot be written as a bare key.
+func Key(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return String(k)
+}
+
+// String returns s as a TOML basic string.
+func String(s string) string {
+	b, _ := json.Marshal(s)
+	// JSON escapes are valid in TOML basic strings, but \u escapes of HTML characters are unnecessary noise.
+	r := strings.NewReplacer(`\u003c`, "<", `\u003e`, ">", `\u0026`, "&")
+	return r.Replace(string(b))
+}
@@ -0,0 +1,99 @@
+package toml_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/internal/toml"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		toml string
+		want map[string]any
+	}{
+		{
+			"flat",
+			"KEY = \"value\" # comment\nLITERAL = 'C:\\path'\nPORT = 5432\nDEBUG = true\n",
+			map[string]any{"KEY": "value", "LITERAL": `C:\path`, "PORT": "5432", "DEBUG": "true"},
+		},
+		{
+			"tables",
+			"[AWS]\nREGION = \"eu-north-1\"\nS3.BUCKET = \"bucket\"\n\n[AWS.SQS]\nQUEUE = \"queue\"\n",
+			map[string]any{"AWS": map[string]any{
+				"REGION": "eu-north-1",
+				"S3":     map[string]any{"BUCKET": "bucket"},
+				"SQS":    map[string]any{"QUEUE": "queue"},
+			}},
+		},
+		{
+			"strings",
+			"BASIC = \"line 1\\nline \\\"2\\\" \\u00e9\"\nMULTILINE = \"\"\"\nline 1\nline 2\"\"\"\n",
+			map[string]any{"BASIC": "line 1\nline \"2\" é", "MULTILINE": "line 1\nline 2"},
+		},
+		{
+			"numbers and dates",
+			"HEX = 0xff\nBIG = 1_000\nPI = 3.14\nWHOLE = 1e3\nAT = 1979-05-27T07:32:00Z\nDAY = 1979-05-27\n",
+			map[string]any{"HEX": "255", "BIG": "1000", "PI": "3.14", "WHOLE": "1000.0", "AT": "1979-05-27T07:32:00Z", "DAY": "1979-05-27"},
+		},
+		{
+			"array of tables",
+			"[[SERVERS]]\nHOST = \"a\"\n[[SERVERS]]\nHOST = \"b\"\n",
+			map[string]any{"SERVERS": []any{map[string]any{"HOST": "a"}, map[string]any{"HOST": "b"}}},
+		},
+		{
+			"arrays and inline tables",
+			"HOSTS = [\"a\", \"b\",\n]\nDB = { HOST = \"localhost\", PORT = 5432 }\n",
+			map[string]any{"HOSTS": []any{"a", "b"}, "DB": map[string]any{"HOST": "localhost", "PORT": "5432"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Decode([]byte(c.toml))
+			if err != nil {
+				t.Fatalf("Decode(%q): %v", c.toml, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Decode(%q) = %#v; want %#v", c.toml, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		toml string
+	}{
+		{"missing value", "KEY =\n"},
+		{"unterminated string", "KEY = \"value\n"},
+		{"duplicate key", "KEY = 1\nKEY = 2\n"},
+		{"bare value", "KEY = nope\n"},
+		{"invalid number", "KEY = 12ab\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Decode([]byte(c.toml))
+			if err == nil {
+				t.Errorf("Decode(%q) = %v, %v; want error", c.toml, got, err)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	s := "a \"quoted\" <value>\n"
+	got := String(s)
+	want := `"a \"quoted\" <value>\n"`
+	if got != want {
+		t.Errorf("String(%q) = %s; want %s", s, got, want)
+	}
+
+	decoded, err := Decode([]byte("KEY = " + got))
+	if err != nil || decoded["KEY"] != s {
+		t.Errorf("Decode(String(%q)) = %v, %v; want round-trip", s, decoded, err)
+	}
+}
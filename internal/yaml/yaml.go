@@ -0,0 +1,209 @@
+// Package yaml decodes YAML documents into nested maps, and encodes them with their keys in a stable order, using
+// gopkg.in/yaml.v3.
+//
+// Decoded mappings are map[string]any, sequences are []any and all scalars are decoded as strings, except for null
+// values which are decoded as nil. Aliases and merge keys are resolved.
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decode decodes the first document in data.
+func Decode(data []byte) (any, error) {
+	docs, err := DecodeAll(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return map[string]any{}, nil
+	}
+	return docs[0], nil
+}
+
+// DecodeAll decodes all documents in data, skipping empty documents.
+func DecodeAll(data []byte) ([]any, error) {
+	var docs []any
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 || isNull(doc.Content[0]) {
+			continue
+		}
+		v, err := value(doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, v)
+	}
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
+
+func value(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return value(n.Alias)
+	case yaml.ScalarNode:
+		if isNull(n) {
+			return nil, nil
+		}
+		return n.Value, nil
+	case yaml.SequenceNode:
+		items := make([]any, len(n.Content))
+		for i, item := range n.Content {
+			v, err := value(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case yaml.MappingNode:
+		return mapping(n)
+	}
+	return nil, fmt.Errorf("line %d: unsupported node", n.Line)
+}
+
+// mapping decodes a mapping node. Keys merged using << are overridden by the keys of the mapping itself, and the
+// mappings of a merged sequence take precedence in order.
+func mapping(n *yaml.Node) (map[string]any, error) {
+	m := make(map[string]any, len(n.Content)/2)
+	merged := make(map[string]any)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Kind == yaml.ScalarNode && k.ShortTag() == "!!merge" {
+			if err := merge(merged, v); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if k.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: mapping keys must be scalars", k.Line)
+		}
+		if _, ok := m[k.Value]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", k.Line, k.Value)
+		}
+		decoded, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		m[k.Value] = decoded
+	}
+	for k, v := range merged {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return m, nil
+}
+
+func merge(into map[string]any, n *yaml.Node) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	var sources []*yaml.Node
+	switch n.Kind {
+	case yaml.MappingNode:
+		sources = []*yaml.Node{n}
+	case yaml.SequenceNode:
+		sources = n.Content
+	default:
+		return fmt.Errorf("line %d: merge key must be a mapping or a sequence of mappings", n.Line)
+	}
+	for _, s := range sources {
+		if s.Kind == yaml.AliasNode {
+			s = s.Alias
+		}
+		if s.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: merge key must be a mapping or a sequence of mappings", s.Line)
+		}
+		m, err := mapping(s)
+		if err != nil {
+			return err
+		}
+		for k, v := range m {
+			if _, ok := into[k]; !ok {
+				into[k] = v
+			}
+		}
+	}
+	return nil
+}
+
+// MapItem is a key-value pair in a MapSlice.
+type MapItem struct {
+	Key   string
+	Value any
+}
+
+// MapSlice is a mapping that is encoded with its keys in order.
+type MapSlice []MapItem
+
+// Marshal encodes v, which may be composed of map[string]any, MapSlice, []any, []string and string values, as a
+// YAML document. Keys of map[string]any are encoded in sorted order and all string values are double-quoted.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node(v)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// node returns v as a YAML node.
+func node(v any) *yaml.Node {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := slices.Sorted(maps.Keys(v))
+		items := make(MapSlice, len(keys))
+		for i, k := range keys {
+			items[i] = MapItem{k, v[k]}
+		}
+		return node(items)
+	case MapSlice:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, item := range v {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item.Key}, node(item.Value))
+		}
+		return n
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return node(items)
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			n.Content = append(n.Content, node(item))
+		}
+		return n
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Style: yaml.DoubleQuotedStyle}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(v)}
+	}
+}
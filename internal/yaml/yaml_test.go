@@ -0,0 +1,131 @@
+package yaml_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/kayex/herofig/internal/yaml"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+		want any
+	}{
+		{
+			"flat mapping",
+			"KEY: value\nQUOTED: \"a \\\"b\\\"\"\nSINGLE: 'it''s'\nNUMBER: 5432 # comment\nEMPTY:\n",
+			map[string]any{"KEY": "value", "QUOTED": `a "b"`, "SINGLE": "it's", "NUMBER": "5432", "EMPTY": nil},
+		},
+		{
+			"nested mapping",
+			"AWS:\n  S3:\n    BUCKET: bucket\n  REGION: eu-north-1\n",
+			map[string]any{"AWS": map[string]any{"S3": map[string]any{"BUCKET": "bucket"}, "REGION": "eu-north-1"}},
+		},
+		{
+			"sequences",
+			"environment:\n  - A=1\n  - B=2\nports: [80, \"443\"]\nitems:\n- name: a\n  value: 1\n- name: b\n",
+			map[string]any{
+				"environment": []any{"A=1", "B=2"},
+				"ports":       []any{"80", "443"},
+				"items":       []any{map[string]any{"name": "a", "value": "1"}, map[string]any{"name": "b"}},
+			},
+		},
+		{
+			"block scalars",
+			"LITERAL: |\n  line 1\n  line 2\nSTRIPPED: |-\n  line\nFOLDED: >\n  a\n  b\nNEXT: value\n",
+			map[string]any{"LITERAL": "line 1\nline 2\n", "STRIPPED": "line", "FOLDED": "a b\n", "NEXT": "value"},
+		},
+		{
+			"flow mapping",
+			"labels: {app: web, tier: \"backend\"}\n",
+			map[string]any{"labels": map[string]any{"app": "web", "tier": "backend"}},
+		},
+		{
+			"tab after colon",
+			"KEY:\tvalue\n",
+			map[string]any{"KEY": "value"},
+		},
+		{
+			"anchors and merge keys",
+			"base: &base\n  A: 1\n  B: 2\nprod:\n  <<: *base\n  B: 3\ncopy: *base\n",
+			map[string]any{
+				"base": map[string]any{"A": "1", "B": "2"},
+				"prod": map[string]any{"A": "1", "B": "3"},
+				"copy": map[string]any{"A": "1", "B": "2"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Decode([]byte(c.yaml))
+			if err != nil {
+				t.Fatalf("Decode(%q): %v", c.yaml, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Decode(%q) = %#v; want %#v", c.yaml, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+	}{
+		{"tab indentation", "K:\n\tA: 1\n"},
+		{"duplicate key", "A: 1\nA: 2\n"},
+		{"unterminated quote", "A: \"value\n"},
+		{"unknown alias", "A: *missing\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Decode([]byte(c.yaml))
+			if err == nil {
+				t.Errorf("Decode(%q) = %#v, %v; want error", c.yaml, got, err)
+			}
+		})
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	docs, err := DecodeAll([]byte("---\na: 1\n---\n# empty\n---\nb: 2\n"))
+	if err != nil {
+		t.Fatalf("DecodeAll: %v", err)
+	}
+	want := []any{map[string]any{"a": "1"}, map[string]any{"b": "2"}}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("DecodeAll = %#v; want %#v", docs, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	v := MapSlice{
+		{"kind", "Secret"},
+		{"metadata", MapSlice{{"name", "app"}}},
+		{"data", map[string]any{"B": "2", "A": "line\n"}},
+		{"items", []any{MapSlice{{"name", "a"}, {"value", "1"}}}},
+	}
+	want := "kind: \"Secret\"\nmetadata:\n  name: \"app\"\ndata:\n  A: \"line\\n\"\n  B: \"2\"\nitems:\n  - name: \"a\"\n    value: \"1\"\n"
+
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got := string(data)
+	if got != want {
+		t.Errorf("Marshal = %q; want %q", got, want)
+	}
+
+	decoded, err := Decode([]byte(got))
+	if err != nil {
+		t.Fatalf("Decode(Marshal): %v", err)
+	}
+	if decoded.(map[string]any)["data"].(map[string]any)["A"] != "line\n" {
+		t.Errorf("Decode(Marshal) = %#v; does not round-trip", decoded)
+	}
+}
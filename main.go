@@ -2,254 +2,869 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"unicode/utf8"
 
+	"github.com/kayex/herofig/herofig"
 	"github.com/kayex/herofig/internal/console"
+	"github.com/kayex/herofig/internal/diff"
 )
 
+var Commands = []*Command{
+	{Name: "get", Args: "key", Summary: "Print the value of a config variable.", Setup: Get, Complete: completeKeys},
+	{Name: "set", Args: "KEY=value...", Summary: "Set the value of config variables.", Setup: Set, Writes: true},
+	{Name: "pull", Args: "[file]", Summary: "Print the application config, or write it to a file.", Setup: Pull, Complete: completeConfigFiles},
+	{Name: "push", Args: "[file...]", Summary: "Set the application config from files.", Setup: Push, Writes: true, Complete: completeConfigFiles},
+	{Name: "push:new", Args: "[file...]", Summary: "Set the config variables from files that are not already set on the application.", Setup: PushNew, Writes: true, Complete: completeConfigFiles},
+	{Name: "search", Args: "query", Summary: "Search for config variables by key.", Setup: Search, Complete: completeKeys},
+	{Name: "hash", Args: "[file...]", Summary: "Compare the hashes of local config files and the application config.", Setup: Hash, Complete: completeConfigFiles},
+	{Name: "render", Args: "[file...]", Summary: "Print the result of merging config files.", Setup: Render, Complete: completeConfigFiles},
+	{Name: "fmt", Args: "[file...]", Summary: "Format env files.", Setup: Fmt, Complete: completeConfigFiles},
+	{Name: "run", Args: "command [args...]", Summary: "Run a command with the application config in its environment.", Setup: RunCommand, Passthrough: true},
+	{Name: "sync", Args: "file", Summary: "Merge the changes made to an env file and the application config since they were last synced.", Setup: Sync, Writes: true, Complete: completeConfigFiles},
+	{Name: "drift", Args: "[files=app...]", Summary: fmt.Sprintf("Compare env files with the config of their applications, and exit with status %d on drift.", ExitDrift), Setup: Drift},
+	{Name: "watch", Args: "file", Summary: "Watch an env file and sync its changes to the application.", Setup: Watch, Writes: true, Interruptible: true, Complete: completeConfigFiles},
+	{Name: "watch:remote", Summary: "Watch the application config and print its changes.", Setup: WatchRemote, Uncached: true, Interruptible: true, Aliases: []string{"watch --remote"}},
+	{Name: "releases", Summary: "List the releases of a file application, latest first.", Setup: Releases},
+	{Name: "rollback", Args: "version", Summary: "Restore the config of an earlier release of a file application.", Setup: Rollback, Writes: true},
+	{Name: "audit:log", Summary: "Print the changes recorded in the audit log.", Setup: AuditLog, Aliases: []string{"audit log"}},
+	{Name: "completion", Args: "bash|zsh|fish", Summary: "Print a shell completion script.", Setup: Completion, Complete: completeShells},
+}
+
 func main() {
-	usageMessage := "Usage: herofig [-a app] get|set|pull|push|push:new|search|hash"
-	// Accept explicit application name using -a and --app flags to be consistent with the Heroku CLI.
-	var a = flag.String("a", "", "The Heroku application name.")
-	var app = flag.String("app", "", "The Heroku application name.")
-	flag.Parse()
-
-	h := NewHeroku(*a)
-	authenticated, err := h.authenticated()
-	if err != nil {
-		console.Fatalln(err)
-	}
-	if !authenticated {
-		console.Fatalln("You must be logged into the Heroku CLI (heroku login)")
-	}
+	console.Classify = classifyError
+	Run(Commands, os.Args[1:])
+}
 
-	command := flag.Arg(0)
-	if command == "" {
-		console.Fatalln(usageMessage)
-	}
-	args := flag.Args()[1:]
+func Get(flags *flag.FlagSet) Runner {
+	templates := addTemplateFlags(flags)
 
-	if *a == "" {
-		a = app
-	}
+	return func(ctx *Context, args []string) {
+		if len(args) < 1 {
+			ctx.UsageFatal()
+		}
+		h := ctx.Backend()
+		key := args[0]
+		tmpl := templates.parse(ctx, h.App(), func() (herofig.Config, error) {
+			return h.Config(ctx)
+		})
+
+		v, err := h.ConfigValue(ctx, key)
+		if err != nil {
+			console.Fatalf("getting value: %v", err)
+		}
+		if ctx.JSON() {
+			printJSON(getOutput{h.App(), key, v})
+			return
+		}
+		if tmpl == nil {
+			fmt.Println(v)
+			return
+		}
 
-	switch command {
-	case "get":
-		Get(h, args)
-	case "set":
-		Set(h, args)
-	case "pull":
-		Pull(h, args)
-	case "push":
-		Push(h, args)
-	case "push:new":
-		PushNew(h, args)
-	case "search":
-		Search(h, args)
-	case "hash":
-		Hash(h, args)
-	default:
-		console.Fatalln(usageMessage)
+		err = herofig.ExecuteTemplate(os.Stdout, tmpl, []herofig.Var{{Key: key, Value: v}})
+		if err != nil {
+			console.Fatalf("rendering template: %v", err)
+		}
 	}
 }
 
-func Get(h *Heroku, args []string) {
-	if len(args) < 1 {
-		console.Fatalln("Usage: herofig get [key]")
-	}
-	key := args[0]
+func Set(*flag.FlagSet) Runner {
+	return func(ctx *Context, args []string) {
+		if len(args) < 1 {
+			ctx.UsageFatal()
+		}
+		h := ctx.Backend()
+		env := ctx.Environment()
+
+		cfg := make(herofig.Config)
+		for _, v := range args {
+			k, v, err := herofig.ParseVar(v)
+			if err != nil {
+				console.Fatalf("parsing variables: %v", err)
+			}
+			cfg[k] = v
+		}
 
-	v, err := h.ConfigValue(key)
-	if err != nil {
-		console.Fatalf("getting value: %v", err)
+		var keys []string
+		for k := range cfg {
+			keys = append(keys, console.ConfigKey(k))
+		}
+
+		checkProtection(h, env)
+		console.Printf("Setting %s on %s...\n", strings.Join(keys, ", "), console.App(h.App()))
+
+		err := h.SetConfig(ctx, cfg)
+		if err != nil {
+			console.Fatalln(err.Error())
+		}
+
+		if ctx.JSON() {
+			printJSON(newSetOutput(h.App(), cfg))
+			return
+		}
+		fmt.Println(console.Success("Successfully set %d configuration %s", len(cfg), pluralize("variable", "", "s", len(cfg))))
 	}
-	fmt.Print(v)
 }
 
-func Set(h *Heroku, args []string) {
-	if len(args) < 1 {
-		console.Fatalln("Usage: herofig set KEY=VALUE")
-	}
+func Pull(flags *flag.FlagSet) Runner {
+	formatName := flags.String("format", "", fmt.Sprintf("The output format (%s). Detected from the file extension by default.", herofig.FormatNames(true)))
+	separator := separatorFlag(flags)
+	name := flags.String("name", "", "The name of exported Kubernetes manifests and Terraform resources. Defaults to the application name.")
+	namespace := flags.String("namespace", "", "The namespace of exported Kubernetes manifests.")
+	stringData := flags.Bool("string-data", false, "Export Kubernetes Secrets using stringData instead of base64-encoded data.")
+	quoted := quotedFlag(flags)
+	templates := addTemplateFlags(flags)
+	selectors := addSelectorFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		h := ctx.Backend()
+		base := ctx.Environment()
+		env := selectors.apply(base)
+
+		var cfg herofig.Config
+		tmpl := templates.parse(ctx, h.App(), func() (herofig.Config, error) {
+			return cfg, nil
+		})
+		if tmpl != nil && *formatName != "" {
+			console.Fatalln("--format cannot be combined with --template or --template-file")
+		}
+
+		var destination string
+		if len(args) > 0 {
+			destination = args[0]
+		}
+		if ctx.JSON() && destination == "" && *formatName != "" {
+			console.Fatalln("--json cannot be combined with --format unless the config is written to a file")
+		}
+		var format *herofig.Format
+		if *formatName != "" {
+			f, err := herofig.FormatByName(*formatName)
+			if err != nil {
+				console.Fatalln(err)
+			}
+			format = &f
+		}
+		opts := herofig.FormatOptions{
+			Separator:  *separator,
+			Name:       *name,
+			Namespace:  *namespace,
+			StringData: *stringData,
+			Warn:       console.Warnf,
+			Quoted:     *quoted,
+		}
+		if opts.Name == "" {
+			opts.Name = h.App()
+		}
+
+		if destination != "" {
+			ok, err := console.ConfirmOverwrite(destination)
+			if err != nil {
+				console.Fatalln(err)
+			}
+			if !ok {
+				console.Fatalln("Aborting")
+			}
+		}
+
+		if destination != "" || (format == nil && tmpl == nil) {
+			console.Printf("Pulling configuration from %s...\n", console.App(h.App()))
+		}
 
-	cfg := make(Config)
-	for _, v := range args {
-		k, v, err := ParseVar(v)
+		remote, err := h.Config(ctx)
 		if err != nil {
-			console.Fatalf("parsing variables: %v", err)
+			console.Fatalf("pulling config: %v", err)
+		}
+		cfg = env.Filter(remote)
+		ordered := cfg.Ordered()
+
+		if tmpl != nil {
+			var buf bytes.Buffer
+			err = herofig.ExecuteTemplate(&buf, tmpl, ordered)
+			if err != nil {
+				console.Fatalf("rendering template: %v", err)
+			}
+			if destination == "" {
+				fmt.Print(buf.String())
+				return
+			}
+			err = os.WriteFile(destination, buf.Bytes(), 0644)
+			if err != nil {
+				console.Fatalf("saving config to %s: %v", destination, err)
+			}
+			console.Println(console.Success("Pulled %d configuration variables into %s", len(cfg), console.FilePath(destination)))
+			return
 		}
-		cfg[k] = v
-	}
 
-	var keys []string
-	for k := range cfg {
-		keys = append(keys, console.ConfigKey(k))
-	}
+		if destination == "" && ctx.JSON() {
+			printJSON(pullOutput{App: h.App(), Config: cfg})
+			return
+		}
+		// Pulls into files with a header are tracked, so redirecting them from standard output loses the tracking.
+		if destination == "" && !console.IsTerminal(os.Stdout) && (format == nil || format.Comment != "") {
+			console.Warnf("Config written to standard output is not tracked, so push cannot check whether %s was changed since the pull. Pass a file to pull into instead.", h.App())
+		}
+		if destination == "" && format != nil {
+			err = format.Encode(os.Stdout, cfg, opts)
+			if err != nil {
+				console.Fatalf("writing config: %v", err)
+			}
+			return
+		}
+		if destination == "" {
+			for _, v := range ordered {
+				fmt.Printf("%s=%s\n", console.ConfigKey(v.Key), console.ConfigValue(v.Value))
+			}
+			return
+		}
 
-	fmt.Printf("Setting %s on %s...\n", strings.Join(keys, ", "), console.App(h.App()))
+		if format == nil {
+			f := herofig.DetectFormat(destination)
+			format = &f
+		}
+		// The recorded hash covers the keys of the environment regardless of --only and --except, so that the file can
+		// be pushed with different selectors.
+		pulledCfg := base.Filter(remote)
+		pulled := herofig.PullRecord{App: h.App(), Hash: fmt.Sprintf("%x", pulledCfg.Hash())}
+		opts.Header = pulled.Header()
+		err = herofig.SaveFormat(destination, cfg, *format, opts)
+		if err != nil {
+			console.Fatalf("saving config to %s: %v", destination, err)
+		}
+		switch {
+		case format.Comment == "" && format.Decode != nil:
+			console.Warnf("%s files cannot record the application they were pulled from, so push cannot check whether %s was changed since the pull.", format.Name, h.App())
+		case format.Comment != "":
+			if err := herofig.SavePullBase(destination, pulled.Hash, herofig.NewSyncBase(pulledCfg)); err != nil {
+				console.Warnf("Saving the pulled config failed, so push cannot show what changed since the pull: %v", err)
+			}
+		}
 
-	err := h.SetConfig(cfg)
-	if err != nil {
-		console.Fatalln(err.Error())
+		if ctx.JSON() {
+			printJSON(pullOutput{App: h.App(), File: destination, Format: format.Name})
+			return
+		}
+		fmt.Println(console.Success(fmt.Sprintf("Pulled %d configuration variables into %s", len(cfg), console.FilePath(destination))))
 	}
-
-	fmt.Println(console.Success("Successfully set %d configuration %s", len(cfg), pluralize("variable", "", "s", len(cfg))))
 }
 
-func Pull(h *Heroku, args []string) {
-	destination := ""
-	if len(args) >= 1 {
-		destination = args[0]
-		if !console.ConfirmOverwrite(destination) {
-			console.Fatalln(console.Error("Aborting"))
+func Push(flags *flag.FlagSet) Runner {
+	sources := addSourceFlags(flags)
+	force := flags.Bool("force", false, "Push even if the application config was changed since the files were pulled.")
+	selectors := addSelectorFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		h := ctx.Backend()
+		base := ctx.Environment()
+		env := selectors.apply(base)
+
+		cfg, files := sources.load(args, env)
+		if len(files) == 0 {
+			ctx.UsageFatal()
+		}
+		checkProtection(h, env)
+		pulled := sources.pulledFiles(files, h.App())
+		var remote herofig.Config
+		if len(pulled) > 0 {
+			var err error
+			if remote, err = h.Config(ctx); err != nil {
+				console.Fatalf("getting config from application: %v", err)
+			}
+			remote = base.Filter(remote)
+			if !*force {
+				checkUnchangedSincePull(pulled, remote, h.App())
+			}
+		}
+
+		err := h.SetConfig(ctx, cfg)
+		if err != nil {
+			console.Fatalf("pushing config: %v", err)
+		}
+		if len(pulled) > 0 {
+			updatePullRecords(pulled, base.Filter(herofig.Merge(remote, cfg)), h.App())
+		}
+
+		if ctx.JSON() {
+			printJSON(newSetOutput(h.App(), cfg))
+			return
 		}
+		fmt.Println(console.Success("Successfully pushed %d configuration %s.", len(cfg), pluralize("variable", "", "s", len(cfg))))
 	}
+}
 
-	fmt.Printf("Pulling configuration from %s...\n", console.App(h.App()))
+func PushNew(flags *flag.FlagSet) Runner {
+	sources := addSourceFlags(flags)
+	selectors := addSelectorFlags(flags)
 
-	cfg, err := h.Config()
-	if err != nil {
-		console.Fatalf("pulling config: %v", err)
+	return func(ctx *Context, args []string) {
+		h := ctx.Backend()
+		env := selectors.apply(ctx.Environment())
+
+		cfg, files := sources.load(args, env)
+		if len(files) == 0 {
+			ctx.UsageFatal()
+		}
+
+		existing, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting existing config from application: %v", err)
+		}
+
+		newConfig := make(map[string]string)
+
+		for k, v := range cfg {
+			if _, exists := existing[k]; !exists {
+				newConfig[k] = v
+			}
+		}
+
+		if len(newConfig) == 0 {
+			if ctx.JSON() {
+				printJSON(newSetOutput(h.App(), newConfig))
+				return
+			}
+			fmt.Println(console.Warning("No new configuration variables."))
+			return
+		}
+
+		checkProtection(h, env)
+		err = h.SetConfig(ctx, newConfig)
+		if err != nil {
+			console.Fatalf("pushing config to application: %v", err)
+		}
+
+		if ctx.JSON() {
+			printJSON(newSetOutput(h.App(), newConfig))
+			return
+		}
+		fmt.Println(console.Success("Successfully pushed %d new configuration %s.", len(newConfig), pluralize("variable", "", "s", len(newConfig))))
 	}
-	ordered := cfg.Ordered()
+}
+
+func Search(flags *flag.FlagSet) Runner {
+	templates := addTemplateFlags(flags)
+	selectors := addSelectorFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		if len(args) < 1 {
+			ctx.UsageFatal()
+		}
+		h := ctx.Backend()
+		env := selectors.apply(ctx.Environment())
+		query := args[0]
+
+		var cfg herofig.Config
+		tmpl := templates.parse(ctx, h.App(), func() (herofig.Config, error) {
+			return cfg, nil
+		})
 
-	if destination == "" {
-		for _, v := range ordered {
-			fmt.Printf("%s=%s\n", console.ConfigKey(v.Key), console.ConfigValue(v.Value))
+		cfg, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting config from application: %v", err)
+		}
+		cfg = env.Filter(cfg)
+
+		if ctx.JSON() {
+			matches := cfg.Filter(func(key string) bool {
+				return len(substringSearch(key, query)) > 0
+			})
+			printJSON(searchOutput{h.App(), query, matches})
+			return
+		}
+		if tmpl != nil {
+			var matches []herofig.Var
+			for _, v := range cfg.Ordered() {
+				if len(substringSearch(v.Key, query)) > 0 {
+					matches = append(matches, v)
+				}
+			}
+			err = herofig.ExecuteTemplate(os.Stdout, tmpl, matches)
+			if err != nil {
+				console.Fatalf("rendering template: %v", err)
+			}
+			return
+		}
+
+		for _, v := range cfg.Ordered() {
+			indices := substringSearch(v.Key, query)
+			if len(indices) > 0 {
+			IterateRunes:
+				// Iterate over individual runes to apply highlighting to characters matched by the search.
+				for pos, r := range []rune(v.Key) {
+					rs := string(r)
+					for _, i := range indices {
+						if pos >= i && pos < i+utf8.RuneCountInString(query) {
+							fmt.Print(console.ConfigKeyHighlighted(rs))
+							continue IterateRunes
+						}
+					}
+					fmt.Print(console.ConfigKey(rs))
+				}
+				fmt.Printf("=%s\n", console.ConfigValue(v.Value))
+			}
 		}
-		return
 	}
+}
 
-	err = Save(destination, cfg)
-	if err != nil {
-		console.Fatalf("saving config to %s: %v", destination, err)
+func Hash(flags *flag.FlagSet) Runner {
+	sources := addSourceFlags(flags)
+	local := flags.Bool("local", false, "Only hash local files, without comparing them to the application config.")
+	selectors := addSelectorFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		env := selectors.apply(ctx.Environment())
+
+		var entries []hashEntry
+		var labels []string
+		add := func(cfg herofig.Config, label string, entry hashEntry) {
+			hash := cfg.Hash()
+			entry.Hash = fmt.Sprintf("%x", hash)
+			entry.Mnemonic = hash.Mnemonic(2)
+			entries = append(entries, entry)
+			labels = append(labels, label)
+		}
+
+		if localCfg, files := sources.load(args, env); len(files) > 0 {
+			fileLabels := make([]string, len(files))
+			for i, f := range files {
+				fileLabels[i] = console.FilePath(f)
+			}
+			add(localCfg, strings.Join(fileLabels, " + "), hashEntry{Files: files})
+		} else {
+			localEnvFiles, err := herofig.FindEnvFiles(".")
+			if err != nil {
+				console.Fatalf("searching for .env files: %v", err)
+			}
+			load := herofig.Load
+			if *sources.quoted {
+				load = herofig.LoadQuoted
+			}
+			for _, envFile := range localEnvFiles {
+				localCfg, err := load(envFile)
+				if err != nil {
+					console.Fatalln(err)
+				}
+				add(env.Filter(localCfg), console.FilePath(envFile), hashEntry{Files: []string{envFile}})
+			}
+		}
+
+		if !*local {
+			h := ctx.Backend()
+			cfg, err := h.Config(ctx)
+			if err != nil {
+				console.Fatalf("getting config from application: %v", err)
+			}
+			add(env.Filter(cfg), console.App(h.App()), hashEntry{App: h.App()})
+		}
+
+		if ctx.JSON() {
+			printJSON(newHashOutput(entries))
+			return
+		}
+
+		var buf bytes.Buffer
+		tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for i, e := range entries {
+			_, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", labels[i], console.ID(e.Mnemonic), e.Hash)
+			if err != nil {
+				console.Fatalln(err)
+			}
+		}
+		_ = tw.Flush()
+		fmt.Print(buf.String())
 	}
+}
+
+func Render(flags *flag.FlagSet) Runner {
+	sources := addSourceFlags(flags)
+
+	return func(ctx *Context, args []string) {
+		env := ctx.Environment()
 
-	fmt.Println(console.Success(fmt.Sprintf("Pulled %d configuration variables into %s", len(cfg), console.FilePath(destination))))
+		cfg, files := sources.load(args, env)
+		if len(files) == 0 {
+			ctx.UsageFatal()
+		}
+		if ctx.JSON() {
+			printJSON(renderOutput{files, cfg})
+			return
+		}
+		write := herofig.Write
+		if *sources.quoted {
+			write = herofig.WriteQuoted
+		}
+		if err := write(os.Stdout, cfg); err != nil {
+			console.Fatalln(err)
+		}
+	}
 }
 
-func Push(h *Heroku, args []string) {
-	if len(args) < 1 {
-		console.Fatalln("Usage: herofig push [env file]")
+func Fmt(flags *flag.FlagSet) Runner {
+	check := flags.Bool("check", false, "Print a diff and exit with a non-zero status if any file is not formatted.")
+	sorted := flags.Bool("sort", false, "Sort variables by key within each comment-delimited section.")
+	quoted := quotedFlag(flags)
+
+	return func(ctx *Context, args []string) {
+		files := args
+		if len(files) == 0 {
+			var err error
+			files, err = herofig.FindEnvFiles(".")
+			if err != nil {
+				console.Fatalf("searching for .env files: %v", err)
+			}
+		}
+
+		out := fmtOutput{Files: []fmtFile{}}
+		unformatted := 0
+		for _, filename := range files {
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				console.Fatalln(err)
+			}
+			parse := herofig.ParseDocument
+			if *quoted {
+				parse = herofig.ParseQuotedDocument
+			}
+			doc, err := parse(bytes.NewReader(src))
+			if err != nil {
+				console.Fatalf("parsing %s: %v", filename, err)
+			}
+			formatted := doc.Format(*sorted).Bytes()
+			if bytes.Equal(src, formatted) {
+				out.Files = append(out.Files, fmtFile{File: filename, Formatted: true})
+				continue
+			}
+			unformatted++
+
+			if *check {
+				lines := diffLines(strings.Split(strings.TrimSuffix(string(src), "\n"), "\n"), doc.Format(*sorted).Lines())
+				out.Files = append(out.Files, fmtFile{File: filename, Diff: lines})
+				if !ctx.JSON() {
+					fmt.Printf("%s\n", console.FilePath(filename))
+					printDiff(lines)
+				}
+				continue
+			}
+
+			info, err := os.Stat(filename)
+			if err != nil {
+				console.Fatalln(err)
+			}
+			err = os.WriteFile(filename, formatted, info.Mode().Perm())
+			if err != nil {
+				console.Fatalf("writing %s: %v", filename, err)
+			}
+			out.Files = append(out.Files, fmtFile{File: filename})
+			if !ctx.JSON() {
+				fmt.Println(console.FilePath(filename))
+			}
+		}
+
+		if ctx.JSON() {
+			printJSON(out)
+			if *check && unformatted > 0 {
+				os.Exit(ExitError)
+			}
+			return
+		}
+		if *check && unformatted > 0 {
+			console.Fatalf("%d %s not formatted", unformatted, pluralize("file", " is", "s are", unformatted))
+		}
 	}
-	source := args[0]
+}
 
-	cfg, err := Load(source)
-	if err != nil {
-		console.Fatalln(err)
+// diffLines returns the lines of the diff between a and b, prefixed with -, + or a space.
+func diffLines(a, b []string) []string {
+	var lines []string
+	for _, e := range diff.Lines(a, b) {
+		switch e.Op {
+		case diff.Delete:
+			lines = append(lines, "-"+e.Text)
+		case diff.Insert:
+			lines = append(lines, "+"+e.Text)
+		default:
+			lines = append(lines, " "+e.Text)
+		}
 	}
+	return lines
+}
 
-	err = h.SetConfig(cfg)
-	if err != nil {
-		console.Fatalf("pushing config: %v", err)
+func printDiff(lines []string) {
+	for _, l := range lines {
+		switch l[0] {
+		case '-':
+			fmt.Println(console.Error("%s", l))
+		case '+':
+			fmt.Println(console.Success("%s", l))
+		default:
+			fmt.Println(l)
+		}
 	}
+}
 
-	fmt.Println(console.Success("Successfully pushed %d configuration %s.", len(cfg), pluralize("variable", "", "s", len(cfg))))
+// sourceFlags are the flags used by commands that read config from local files.
+type sourceFlags struct {
+	mode      *string
+	format    *string
+	separator *string
+	service   *string
+	name      *string
+	quoted    *bool
+	// modeFiles are the files loaded for --mode, which are always env files.
+	modeFiles map[string]bool
 }
 
-func PushNew(h *Heroku, args []string) {
-	if len(args) < 1 {
-		console.Fatalln("Usage: herofig push:new [env file]")
+func addSourceFlags(flags *flag.FlagSet) sourceFlags {
+	return sourceFlags{
+		mode:      flags.String("mode", "", "Load .env, .env.local, .env.<mode> and .env.<mode>.local before any given files."),
+		format:    flags.String("format", "", fmt.Sprintf("The input format (%s). Detected from the file extension by default.", herofig.FormatNames(false))),
+		separator: separatorFlag(flags),
+		service:   flags.String("service", "", "The docker-compose service to read the environment of."),
+		name:      flags.String("name", "", "Only read Kubernetes manifests with this name."),
+		quoted:    quotedFlag(flags),
+		modeFiles: make(map[string]bool),
 	}
-	source := args[0]
+}
+
+type selectorFlags struct {
+	only   *stringsFlag
+	except *stringsFlag
+}
 
-	existing, err := h.Config()
+func addSelectorFlags(flags *flag.FlagSet) selectorFlags {
+	s := selectorFlags{new(stringsFlag), new(stringsFlag)}
+	flags.Var(s.only, "only", "Only use keys matching a glob, re:regex or prefix:prefix pattern. May be repeated.")
+	flags.Var(s.except, "except", "Leave out keys matching a glob, re:regex or prefix:prefix pattern. May be repeated.")
+	return s
+}
+
+// apply returns env restricted to the keys selected by --only and --except.
+func (s selectorFlags) apply(env herofig.Environment) herofig.Environment {
+	only, err := herofig.ParsePatterns(*s.only)
 	if err != nil {
-		console.Fatalf("getting existing config from application: %v", err)
+		console.Fatalln(err)
 	}
-
-	cfg, err := Load(source)
+	except, err := herofig.ParsePatterns(*s.except)
 	if err != nil {
 		console.Fatalln(err)
 	}
+	env.Selector = herofig.Selector{Only: only, Except: except}
+	return env
+}
 
-	newConfig := make(map[string]string)
+func quotedFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("quoted", false, "Decode quoted values in env files, and quote values where necessary when writing them, instead of using values verbatim.")
+}
 
-	for k, v := range cfg {
-		if _, exists := existing[k]; !exists {
-			newConfig[k] = v
+func separatorFlag(flags *flag.FlagSet) *string {
+	return flags.String("separator", "_", "The separator used to join the keys of nested objects.")
+}
+
+// load loads and merges files from left to right, preceded by the env files for the selected mode if it is set.
+// If neither files nor a mode are given, the files of env are loaded. Keys ignored by env are left out.
+// It returns the merged config and the files it was loaded from.
+func (s sourceFlags) load(files []string, env herofig.Environment) (herofig.Config, []string) {
+	if len(files) == 0 && *s.mode == "" {
+		files = env.Files
+	}
+	if *s.mode != "" {
+		modeFiles, err := herofig.ModeFiles(".", *s.mode)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		for _, f := range modeFiles {
+			s.modeFiles[f] = true
 		}
+		files = append(modeFiles, files...)
 	}
 
-	if len(newConfig) == 0 {
-		fmt.Println(console.Warning("No new configuration variables."))
-		return
+	opts := herofig.FormatOptions{
+		Separator: *s.separator,
+		Service:   *s.service,
+		Name:      *s.name,
+		Warn:      console.Warnf,
+		Quoted:    *s.quoted,
 	}
+	cfgs := make([]herofig.Config, 0, len(files))
+	for _, file := range files {
+		cfg, err := herofig.LoadFormat(file, s.formatOf(file), opts)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return env.Filter(herofig.Merge(cfgs...)), files
+}
 
-	err = h.SetConfig(newConfig)
+// formatOf returns the format of file, which is the format given by --format if it is set, except for the env files
+// loaded for --mode.
+func (s sourceFlags) formatOf(file string) herofig.Format {
+	if *s.format == "" || s.modeFiles[file] {
+		return herofig.DetectFormat(file)
+	}
+	format, err := herofig.FormatByName(*s.format)
 	if err != nil {
-		console.Fatalf("pushing config to application: %v", err)
+		console.Fatalln(err)
 	}
+	return format
+}
 
-	fmt.Println(console.Success("Successfully pushed %d new configuration %s.", len(newConfig), pluralize("variable", "", "s", len(newConfig))))
+// pulledFile is a file with a record of when it was pulled from an application.
+type pulledFile struct {
+	file   string
+	format herofig.Format
+	record herofig.PullRecord
 }
 
-func Search(h *Heroku, args []string) {
-	if len(args) < 1 {
-		fmt.Println("Usage: herofig search [query]")
+// pulledFiles returns the files that were pulled from app.
+func (s sourceFlags) pulledFiles(files []string, app string) []pulledFile {
+	var pulled []pulledFile
+	for _, file := range files {
+		format := s.formatOf(file)
+		r, err := herofig.ReadPullRecord(file, format)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		if r != nil && r.App == app {
+			pulled = append(pulled, pulledFile{file, format, *r})
+		}
 	}
-	query := args[0]
+	return pulled
+}
 
-	cfg, err := h.Config()
-	if err != nil {
-		console.Fatalf("getting config from application: %v", err)
-	}
-
-	for _, v := range cfg.Ordered() {
-		indices := substringSearch(v.Key, query)
-		if len(indices) > 0 {
-		IterateRunes:
-			// Iterate over individual runes to apply highlighting to characters matched by the search.
-			for pos, r := range []rune(v.Key) {
-				rs := string(r)
-				for _, i := range indices {
-					if pos >= i && pos < i+utf8.RuneCountInString(query) {
-						fmt.Print(console.ConfigKeyHighlighted(rs))
-						continue IterateRunes
-					}
+// checkUnchangedSincePull aborts if the config of app was changed since any of the files were pulled, showing the
+// changes if the pulled config is known.
+func checkUnchangedSincePull(pulled []pulledFile, remote herofig.Config, app string) {
+	hash := fmt.Sprintf("%x", remote.Hash())
+	for _, p := range pulled {
+		if p.record.Hash == hash {
+			continue
+		}
+		msg := fmt.Sprintf("%s was changed since %s was pulled.", app, p.file)
+		if base, err := herofig.LoadPullBase(p.file, p.record.Hash); err == nil && base != nil {
+			for _, c := range base.Changes(remote) {
+				switch c.Kind {
+				case herofig.Added:
+					msg += fmt.Sprintf("\n  + %s=%s", c.Key, herofig.Mask(c.New))
+				case herofig.Removed:
+					msg += fmt.Sprintf("\n  - %s", c.Key)
+				default:
+					msg += fmt.Sprintf("\n  ~ %s=%s", c.Key, herofig.Mask(c.New))
 				}
-				fmt.Print(console.ConfigKey(rs))
 			}
-			fmt.Printf("=%s\n", console.ConfigValue(v.Value))
+		}
+		console.Fatalln(herofig.Errorf(herofig.ErrConflict, "%s\nPull again to merge the changes, or pass --force to overwrite them.", msg))
+	}
+}
+
+// updatePullRecords records that the files are up to date with cfg, the config of app after they were pushed.
+func updatePullRecords(pulled []pulledFile, cfg herofig.Config, app string) {
+	r := herofig.PullRecord{App: app, Hash: fmt.Sprintf("%x", cfg.Hash())}
+	for _, p := range pulled {
+		if err := herofig.WritePullRecord(p.file, p.format, r); err != nil {
+			console.Warnf("Updating the header of %s failed: %v", p.file, err)
+			continue
+		}
+		if err := herofig.SavePullBase(p.file, r.Hash, herofig.NewSyncBase(cfg)); err != nil {
+			console.Warnf("Saving the pushed config of %s failed: %v", p.file, err)
 		}
 	}
 }
 
-func Hash(h *Heroku, args []string) {
-	var buf bytes.Buffer
-	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+// checkProtection aborts unless the config of the application of h may be changed, asking for confirmation if required.
+func checkProtection(h herofig.Backend, env herofig.Environment) {
+	if err := confirmProtection(h, env); err != nil {
+		console.Fatalln(err)
+	}
+}
 
-	localEnvFiles, err := FindEnvFiles(".")
+// confirmProtection returns an error unless the config of the application of h may be changed, asking for
+// confirmation if required.
+func confirmProtection(h herofig.Backend, env herofig.Environment) error {
+	p, err := protection(h, env)
 	if err != nil {
-		console.Fatalf("searching for .env files: %v", err)
+		return err
+	}
+	name := h.App()
+	if env.Name != "" {
+		name = fmt.Sprintf("Environment %s (%s)", env.Name, h.App())
 	}
-	for _, envFile := range localEnvFiles {
-		localCfg, err := Load(envFile)
+	switch p {
+	case herofig.ReadOnly:
+		return herofig.Errorf(herofig.ErrPermission, "%s is read-only", name)
+	case herofig.ConfirmWrites:
+		ok, err := console.Confirm(fmt.Sprintf("%s is protected.", name), "Continue?", false)
 		if err != nil {
-			console.Fatalln(err)
+			return err
 		}
-
-		hash := localCfg.Hash()
-		_, err = fmt.Fprintf(tw, "%s\t%s\t%x\n", console.FilePath(envFile), console.ID(hash.Mnemonic(2)), hash)
-		if err != nil {
-			console.Fatalln(err)
+		if !ok {
+			return errors.New("Aborting")
 		}
 	}
+	return nil
+}
 
-	cfg, err := h.Config()
+// protection returns the protection of the application of h, which is that of env if it is protected, and otherwise
+// the strictest protection of the environments in the project file that use the application. Selecting an
+// application by name, such as with -a, therefore does not bypass the protection of its environments.
+func protection(h herofig.Backend, env herofig.Environment) (herofig.Protection, error) {
+	if env.Protection != herofig.Unprotected {
+		return env.Protection, nil
+	}
+	project, err := herofig.FindProject(".")
 	if err != nil {
-		console.Fatalf("getting config from application: %v", err)
+		return "", fmt.Errorf("reading %s: %w", herofig.ProjectFilename, err)
+	}
+	if project == nil {
+		return herofig.Unprotected, nil
+	}
+	return project.Protection(h.App()), nil
+}
+
+type templateFlags struct {
+	text *string
+	file *string
+}
+
+func addTemplateFlags(flags *flag.FlagSet) templateFlags {
+	return templateFlags{
+		text: flags.String("template", "", "A Go text/template to render the output with."),
+		file: flags.String("template-file", "", "A file containing a Go text/template to render the output with."),
+	}
+}
+
+// parse returns the template selected by the flags, or nil if no template was given. The template metadata is
+// read from app and config.
+func (t templateFlags) parse(ctx *Context, app string, config func() (herofig.Config, error)) *template.Template {
+	text := *t.text
+	if *t.file != "" {
+		if text != "" {
+			console.Fatalln("--template and --template-file cannot be combined")
+		}
+		b, err := os.ReadFile(*t.file)
+		if err != nil {
+			console.Fatalf("reading template: %v", err)
+		}
+		text = string(b)
+	}
+	if text == "" {
+		return nil
 	}
-	hash := cfg.Hash()
-	_, err = fmt.Fprintf(tw, "%s\t%s\t%x\n", console.App(h.App()), console.ID(hash.Mnemonic(2)), hash)
+	if ctx.JSON() {
+		console.Fatalln("--json cannot be combined with --template or --template-file")
+	}
+
+	tmpl, err := herofig.NewTemplate(text, herofig.TemplateMeta{App: app, Config: config})
 	if err != nil {
-		console.Fatalln(err)
+		console.Fatalf("parsing template: %v", err)
 	}
-	err = tw.Flush()
-	fmt.Print(buf.String())
+	return tmpl
 }
 
 func substringSearch(haystack, needle string) []int {
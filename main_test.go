@@ -1,9 +1,16 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
 )
 
 func TestSubstringSearch(t *testing.T) {
@@ -29,3 +36,88 @@ func TestSubstringSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestSourceFlags_Load(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		".env":         "A=1\nB=1\n",
+		".env.staging": "B=2\n",
+		"config.txt":   `{"C": "3"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flags := flag.NewFlagSet("push", flag.ContinueOnError)
+	sources := addSourceFlags(flags)
+	if err := flags.Parse([]string{"--mode", "staging", "--format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// --format only applies to the given files, not to the env files loaded for --mode.
+	cfg, loaded := sources.load([]string{"config.txt"}, herofig.Environment{})
+	want := herofig.Config{"A": "1", "B": "2", "C": "3"}
+	if !maps.Equal(cfg, want) {
+		t.Errorf("load() = %v; want %v", cfg, want)
+	}
+	if wantFiles := []string{".env", ".env.staging", "config.txt"}; !slices.Equal(loaded, wantFiles) {
+		t.Errorf("load() files = %v; want %v", loaded, wantFiles)
+	}
+}
+
+func TestConfirmProtection(t *testing.T) {
+	dir := t.TempDir()
+	project := `{"environments": {
+		"production": {"app": "file:prod.json", "protection": "readonly"},
+		"staging": {"app": "file:staging.json", "protection": "confirm"}
+	}}`
+	if err := os.WriteFile(filepath.Join(dir, herofig.ProjectFilename), []byte(project), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("HEROFIG_APP", "")
+	t.Setenv("HEROFIG_ENV", "")
+	prompts := console.Prompts
+	console.Prompts = console.PromptNever
+	t.Cleanup(func() { console.Prompts = prompts })
+
+	// The error codes of classifyError.
+	cases := []struct {
+		name string
+		app  string
+		env  string
+		want string
+	}{
+		{"-a read-only app", "file:prod.json", "", "permission"},
+		{"-a relative path", "file:./prod.json", "", "permission"},
+		{"-a protected app", "file:staging.json", "", "confirmation_required"},
+		{"-a other app", "file:dev.json", "", ""},
+		{"--env read-only", "", "production", "permission"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			remote := ""
+			ctx := &Context{app: &c.app, env: &c.env, remote: &remote}
+			app, err := ctx.App()
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, err := herofig.NewBackend(app)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = confirmProtection(h, ctx.Environment())
+			got := ""
+			if err != nil {
+				got, _ = classifyError(err)
+			}
+			if got != c.want {
+				t.Errorf("confirmProtection() error = %v; want %q", err, c.want)
+			}
+		})
+	}
+}
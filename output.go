@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// The documents printed by commands when --json is given. Their field names are part of the command line interface
+// and must not change. Errors are printed as {"error": {"code": ..., "message": ...}} instead.
+
+type getOutput struct {
+	App   string `json:"app"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// setOutput is printed by set, push and push:new. Only the keys that were set are included, never their values.
+type setOutput struct {
+	App  string   `json:"app"`
+	Keys []string `json:"keys"`
+}
+
+type pullOutput struct {
+	App string `json:"app"`
+	// Config is only included when the config is not written to a file.
+	Config herofig.Config `json:"config,omitzero"`
+	File   string         `json:"file,omitempty"`
+	Format string         `json:"format,omitempty"`
+}
+
+type searchOutput struct {
+	App     string         `json:"app"`
+	Query   string         `json:"query"`
+	Matches herofig.Config `json:"matches"`
+}
+
+type hashOutput struct {
+	Hashes []hashEntry `json:"hashes"`
+	// Equal reports whether all hashes are equal.
+	Equal bool `json:"equal"`
+}
+
+// hashEntry is the hash of the config of either local files or an application.
+type hashEntry struct {
+	Files    []string `json:"files,omitempty"`
+	App      string   `json:"app,omitempty"`
+	Hash     string   `json:"hash"`
+	Mnemonic string   `json:"mnemonic"`
+}
+
+type renderOutput struct {
+	Files  []string       `json:"files"`
+	Config herofig.Config `json:"config"`
+}
+
+type fmtOutput struct {
+	Files []fmtFile `json:"files"`
+}
+
+type fmtFile struct {
+	File string `json:"file"`
+	// Formatted reports whether the file was already formatted.
+	Formatted bool `json:"formatted"`
+	// Diff is the diff between the file and its formatted version, in unified diff syntax without headers. It is
+	// only included with --check.
+	Diff []string `json:"diff,omitempty"`
+}
+
+type completionOutput struct {
+	Shell  string `json:"shell"`
+	Script string `json:"script"`
+}
+
+// watchOutput is printed for each change to the application config by watch --remote. Values are left out, since
+// the output is likely to end up in logs.
+type watchOutput struct {
+	App     string        `json:"app"`
+	Time    time.Time     `json:"time"`
+	Changes []watchChange `json:"changes"`
+}
+
+type watchChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+}
+
+func newWatchOutput(app string, t time.Time, changes []herofig.Change) watchOutput {
+	out := watchOutput{App: app, Time: t, Changes: make([]watchChange, len(changes))}
+	for i, c := range changes {
+		out.Changes[i] = watchChange{c.Key, c.Kind.String()}
+	}
+	return out
+}
+
+// driftOutput is printed by drift. Values are only included as fingerprints, so that the report can be logged.
+type driftOutput struct {
+	Targets []driftReport `json:"targets"`
+	Drifted bool          `json:"drifted"`
+}
+
+type driftReport struct {
+	Environment string     `json:"environment,omitempty"`
+	App         string     `json:"app"`
+	Files       []string   `json:"files"`
+	Drifted     bool       `json:"drifted"`
+	Keys        []driftKey `json:"keys"`
+}
+
+// The kinds of drift of a key.
+const (
+	driftAddedOnApp   = "added_on_app"
+	driftMissingOnApp = "missing_on_app"
+	driftChanged      = "changed"
+)
+
+type driftKey struct {
+	Key   string `json:"key"`
+	Drift string `json:"drift"`
+	// File and App are the fingerprints of the value in the files and on the application.
+	File string `json:"file,omitempty"`
+	App  string `json:"app,omitempty"`
+}
+
+// newDriftReport returns a report of the changes that turn the config of files into the config of app.
+func newDriftReport(env, app string, files []string, changes []herofig.Change) driftReport {
+	r := driftReport{Environment: env, App: app, Files: files, Drifted: len(changes) > 0, Keys: make([]driftKey, len(changes))}
+	for i, c := range changes {
+		k := driftKey{Key: c.Key}
+		switch c.Kind {
+		case herofig.Added:
+			k.Drift, k.App = driftAddedOnApp, herofig.Fingerprint(c.New)
+		case herofig.Removed:
+			k.Drift, k.File = driftMissingOnApp, herofig.Fingerprint(c.Old)
+		default:
+			k.Drift, k.File, k.App = driftChanged, herofig.Fingerprint(c.Old), herofig.Fingerprint(c.New)
+		}
+		r.Keys[i] = k
+	}
+	return r
+}
+
+type syncOutput struct {
+	App    string   `json:"app"`
+	File   string   `json:"file"`
+	Pushed []string `json:"pushed"`
+	Pulled []string `json:"pulled"`
+	// NotUnset are the keys that were removed from the file, but cannot be unset on the application.
+	NotUnset []string `json:"not_unset"`
+}
+
+func newSyncOutput(app, file string) syncOutput {
+	return syncOutput{App: app, File: file, Pushed: []string{}, Pulled: []string{}, NotUnset: []string{}}
+}
+
+// releaseEntry is a release without its config snapshot, whose values are never printed.
+type releaseEntry struct {
+	Version     int       `json:"version"`
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+}
+
+type releasesOutput struct {
+	App      string         `json:"app"`
+	Releases []releaseEntry `json:"releases"`
+}
+
+func newReleasesOutput(app string, releases []herofig.Release) releasesOutput {
+	out := releasesOutput{App: app, Releases: make([]releaseEntry, len(releases))}
+	for i, r := range releases {
+		out.Releases[i] = releaseEntry{r.Version, r.Time, r.Description}
+	}
+	return out
+}
+
+type rollbackOutput struct {
+	App     string `json:"app"`
+	Version int    `json:"version"`
+}
+
+type auditOutput struct {
+	Entries []herofig.AuditEntry `json:"entries"`
+}
+
+func newAuditOutput(entries []herofig.AuditEntry) auditOutput {
+	if entries == nil {
+		entries = []herofig.AuditEntry{}
+	}
+	return auditOutput{entries}
+}
+
+func newSetOutput(app string, cfg herofig.Config) setOutput {
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return setOutput{app, keys}
+}
+
+func newHashOutput(entries []hashEntry) hashOutput {
+	out := hashOutput{Hashes: entries, Equal: true}
+	if out.Hashes == nil {
+		out.Hashes = []hashEntry{}
+	}
+	for _, e := range entries {
+		if e.Hash != entries[0].Hash {
+			out.Equal = false
+		}
+	}
+	return out
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		console.Fatalln(err)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+// TestOutputSchema guards the field names of the --json output, which scripts depend on.
+func TestOutputSchema(t *testing.T) {
+	cases := []struct {
+		name   string
+		output any
+		want   string
+	}{
+		{"get", getOutput{"my-app", "KEY", "value"}, `{"app":"my-app","key":"KEY","value":"value"}`},
+		{"set", newSetOutput("my-app", herofig.Config{"B": "2", "A": "1"}), `{"app":"my-app","keys":["A","B"]}`},
+		{"set nothing", newSetOutput("my-app", herofig.Config{}), `{"app":"my-app","keys":[]}`},
+		{"pull", pullOutput{App: "my-app", Config: herofig.Config{"KEY": "value"}}, `{"app":"my-app","config":{"KEY":"value"}}`},
+		{"pull empty", pullOutput{App: "my-app", Config: herofig.Config{}}, `{"app":"my-app","config":{}}`},
+		{"pull to file", pullOutput{App: "my-app", File: "app.env", Format: "env"}, `{"app":"my-app","file":"app.env","format":"env"}`},
+		{"search", searchOutput{"my-app", "KE", herofig.Config{"KEY": "value"}}, `{"app":"my-app","query":"KE","matches":{"KEY":"value"}}`},
+		{
+			"hash",
+			newHashOutput([]hashEntry{
+				{Files: []string{".env"}, Hash: "ab", Mnemonic: "lusab-babad"},
+				{App: "my-app", Hash: "ab", Mnemonic: "lusab-babad"},
+			}),
+			`{"hashes":[{"files":[".env"],"hash":"ab","mnemonic":"lusab-babad"},{"app":"my-app","hash":"ab","mnemonic":"lusab-babad"}],"equal":true}`,
+		},
+		{
+			"hash mismatch",
+			newHashOutput([]hashEntry{{App: "a", Hash: "ab", Mnemonic: "x"}, {App: "b", Hash: "cd", Mnemonic: "y"}}),
+			`{"hashes":[{"app":"a","hash":"ab","mnemonic":"x"},{"app":"b","hash":"cd","mnemonic":"y"}],"equal":false}`,
+		},
+		{"hash nothing", newHashOutput(nil), `{"hashes":[],"equal":true}`},
+		{"render", renderOutput{[]string{".env"}, herofig.Config{"KEY": "value"}}, `{"files":[".env"],"config":{"KEY":"value"}}`},
+		{
+			"fmt",
+			fmtOutput{[]fmtFile{{File: "a.env", Formatted: true}, {File: "b.env", Diff: []string{"-A=1", "+A=2"}}}},
+			`{"files":[{"file":"a.env","formatted":true},{"file":"b.env","formatted":false,"diff":["-A=1","+A=2"]}]}`,
+		},
+		{
+			"watch",
+			newWatchOutput("my-app", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), []herofig.Change{
+				{Kind: herofig.Added, Key: "A", New: "1"},
+				{Kind: herofig.Removed, Key: "B", Old: "2"},
+			}),
+			`{"app":"my-app","time":"2024-05-01T12:00:00Z","changes":[{"key":"A","change":"added"},{"key":"B","change":"removed"}]}`,
+		},
+		{
+			"drift",
+			driftOutput{
+				Targets: []driftReport{
+					newDriftReport("production", "my-app", []string{"a.env"}, []herofig.Change{
+						{Kind: herofig.Added, Key: "A", New: "1"},
+						{Kind: herofig.Changed, Key: "B", Old: "1", New: "2"},
+						{Kind: herofig.Removed, Key: "C", Old: "1"},
+					}),
+					newDriftReport("", "other-app", []string{"b.env"}, nil),
+				},
+				Drifted: true,
+			},
+			`{"targets":[{"environment":"production","app":"my-app","files":["a.env"],"drifted":true,"keys":[` +
+				`{"key":"A","drift":"added_on_app","app":"6b86b273ff34fce1"},` +
+				`{"key":"B","drift":"changed","file":"6b86b273ff34fce1","app":"d4735e3a265e16ee"},` +
+				`{"key":"C","drift":"missing_on_app","file":"6b86b273ff34fce1"}]},` +
+				`{"app":"other-app","files":["b.env"],"drifted":false,"keys":[]}],"drifted":true}`,
+		},
+		{"sync", syncOutput{"my-app", "app.env", []string{"A"}, []string{"B"}, []string{}}, `{"app":"my-app","file":"app.env","pushed":["A"],"pulled":["B"],"not_unset":[]}`},
+		{"sync nothing", newSyncOutput("my-app", "app.env"), `{"app":"my-app","file":"app.env","pushed":[],"pulled":[],"not_unset":[]}`},
+		{
+			"releases",
+			newReleasesOutput("file:app.json", []herofig.Release{
+				{Version: 1, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Description: "Set A config vars", Config: herofig.Config{"A": "secret"}},
+			}),
+			`{"app":"file:app.json","releases":[{"version":1,"time":"2024-05-01T12:00:00Z","description":"Set A config vars"}]}`,
+		},
+		{"releases nothing", newReleasesOutput("file:app.json", nil), `{"app":"file:app.json","releases":[]}`},
+		{"rollback", rollbackOutput{"file:app.json", 1}, `{"app":"file:app.json","version":1}`},
+		{"audit nothing", newAuditOutput(nil), `{"entries":[]}`},
+		{
+			"audit",
+			newAuditOutput([]herofig.AuditEntry{{
+				Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				User:    "alice",
+				Account: "alice@example.com",
+				App:     "my-app",
+				Command: "set",
+				Changes: []herofig.AuditChange{{Key: "A", Before: "ab", After: "cd"}, {Key: "B", After: "ef"}},
+			}}),
+			`{"entries":[{"time":"2024-05-01T12:00:00Z","user":"alice","account":"alice@example.com","app":"my-app","command":"set","changes":[{"key":"A","before":"ab","after":"cd"},{"key":"B","after":"ef"}]}]}`,
+		},
+		{"completion", completionOutput{"bash", "complete"}, `{"shell":"bash","script":"complete"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := json.Marshal(c.output)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			if string(b) != c.want {
+				t.Errorf("got %s; want %s", b, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+func Releases(flags *flag.FlagSet) Runner {
+	return func(ctx *Context, args []string) {
+		if len(args) != 0 {
+			ctx.UsageFatal()
+		}
+		h := ctx.Backend()
+		r, ok := h.(herofig.Releaser)
+		if !ok {
+			console.Fatalln(herofig.Errorf(herofig.ErrValidation, "%s has no releases, which are only kept for %s applications", h.App(), herofig.FilePrefix))
+		}
+		releases, err := r.Releases(ctx)
+		if err != nil {
+			console.Fatalf("getting releases: %v", err)
+		}
+
+		if ctx.JSON() {
+			printJSON(newReleasesOutput(h.App(), releases))
+			return
+		}
+		if len(releases) == 0 {
+			console.Println(console.Warning("%s has no releases", h.App()))
+			return
+		}
+		// Like heroku releases, the latest release is listed first.
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for i := len(releases) - 1; i >= 0; i-- {
+			r := releases[i]
+			fmt.Fprintf(tw, "v%d\t%s\t%s\n", r.Version, r.Time.Local().Format(time.DateTime), r.Description)
+		}
+		tw.Flush()
+	}
+}
+
+func Rollback(flags *flag.FlagSet) Runner {
+	return func(ctx *Context, args []string) {
+		if len(args) != 1 {
+			ctx.UsageFatal()
+		}
+		version, err := strconv.Atoi(strings.TrimPrefix(args[0], "v"))
+		if err != nil || version < 1 {
+			console.Fatalln(herofig.Errorf(herofig.ErrValidation, "invalid release %q (must be a version such as v3)", args[0]))
+		}
+		h := ctx.Backend()
+		r, ok := h.(herofig.Releaser)
+		if !ok {
+			console.Fatalln(herofig.Errorf(herofig.ErrValidation, "%s has no releases, which are only kept for %s applications", h.App(), herofig.FilePrefix))
+		}
+
+		checkProtection(h, ctx.Environment())
+		if err := r.Rollback(ctx, version); err != nil {
+			console.Fatalf("rolling back %s: %v", h.App(), err)
+		}
+		if ctx.JSON() {
+			printJSON(rollbackOutput{h.App(), version})
+			return
+		}
+		fmt.Println(console.Success("Rolled back %s to v%d", console.App(h.App()), version))
+	}
+}
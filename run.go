@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+// envFilePlaceholder is replaced by the path of the temporary env file in the arguments of the command.
+const envFilePlaceholder = "{env-file}"
+
+// forwardedSignals are the signals that are forwarded to the command instead of stopping herofig.
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// terminalSignals are the signals that the terminal sends to the whole foreground process group, which includes the
+// command. They are caught so that they do not stop herofig, but not forwarded, so that the command receives them once.
+var terminalSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+
+func RunCommand(flags *flag.FlagSet) Runner {
+	var overlays, excludes stringsFlag
+	flags.Var(&overlays, "overlay", "A local config file to merge over the application config. May be repeated.")
+	flags.Var(&excludes, "exclude", "A glob pattern of keys to leave out. May be repeated.")
+	replace := flags.Bool("replace", false, "Replace the current environment instead of merging the config over it.")
+	envFile := flags.Bool("env-file", false, "Write the config to a temporary env file, which is deleted when the command exits. Its path is in HEROFIG_ENV_FILE and replaces "+envFilePlaceholder+" in the arguments.")
+
+	return func(ctx *Context, args []string) {
+		if len(args) < 1 {
+			ctx.UsageFatal()
+		}
+		for _, pattern := range excludes {
+			if _, err := path.Match(pattern, ""); err != nil {
+				console.Fatalln(herofig.Errorf(herofig.ErrValidation, "invalid exclude pattern %q", pattern))
+			}
+		}
+		h := ctx.Backend()
+		env := ctx.Environment()
+
+		cfg, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting config: %v", err)
+		}
+		cfgs := []herofig.Config{cfg}
+		for _, file := range overlays {
+			overlay, err := herofig.LoadFormat(file, herofig.DetectFormat(file), herofig.FormatOptions{Separator: "_", Warn: console.Warnf})
+			if err != nil {
+				console.Fatalln(err)
+			}
+			cfgs = append(cfgs, overlay)
+		}
+		cfg = env.Filter(herofig.Merge(cfgs...)).Filter(func(key string) bool {
+			for _, pattern := range excludes {
+				if ok, _ := path.Match(pattern, key); ok {
+					return false
+				}
+			}
+			return true
+		})
+
+		var base []string
+		if !*replace {
+			base = os.Environ()
+		}
+		environ := herofig.Environ(base, cfg)
+
+		// Deferred functions do not run when herofig exits with the status of the command, so the env file is
+		// removed explicitly.
+		cleanup := func() {}
+		if *envFile {
+			name, err := writeTempEnvFile(cfg)
+			if err != nil {
+				console.Fatalf("writing env file: %v", err)
+			}
+			cleanup = func() { os.Remove(name) }
+			environ = append(environ, "HEROFIG_ENV_FILE="+name)
+			for i, a := range args {
+				args[i] = strings.ReplaceAll(a, envFilePlaceholder, name)
+			}
+		}
+
+		status, err := run(args, environ)
+		cleanup()
+		if err != nil {
+			console.Fatalf("running %s: %v", args[0], err)
+		}
+		os.Exit(status)
+	}
+}
+
+// run runs a command with the environment environ, forwarding signals to it until it exits. It returns the exit
+// status of the command, or an error if it could not be started.
+func run(args, environ []string) (int, error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, slices.Concat(forwardedSignals, terminalSignals)...)
+	defer signal.Stop(signals)
+	return runWithSignals(args, environ, signals)
+}
+
+// runWithSignals runs a command like run, forwarding the signals received on signals to it until it exits, when it
+// stops receiving them.
+func runWithSignals(args, environ []string, signals <-chan os.Signal) (int, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = environ
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		for {
+			select {
+			case s := <-signals:
+				if slices.Contains(forwardedSignals, s) {
+					cmd.Process.Signal(s)
+				}
+			case <-exited:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	var ee *exec.ExitError
+	if err != nil && !errors.As(err, &ee) {
+		return 0, err
+	}
+	return exitStatus(cmd.ProcessState), nil
+}
+
+// exitStatus returns the exit status of a process the way shells report it, as 128 plus the signal number if the
+// process was killed by a signal.
+func exitStatus(state *os.ProcessState) int {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return state.ExitCode()
+}
+
+// writeTempEnvFile writes cfg to a new env file that only the current user can read, and returns its name. Values
+// are quoted where necessary, so that values spanning multiple lines can be read back.
+func writeTempEnvFile(cfg herofig.Config) (string, error) {
+	f, err := os.CreateTemp("", "herofig-*.env")
+	if err != nil {
+		return "", err
+	}
+	if err := herofig.WriteQuoted(f, cfg); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
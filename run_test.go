@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		want   int
+	}{
+		{"success", "exit 0", 0},
+		{"failure", "exit 3", 3},
+		{"environment", `test "$A" = "1 2" && test -z "$HOME"`, 0},
+		{"signal", "kill -TERM $$", 128 + 15},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, err := run([]string{"sh", "-c", c.script}, []string{"A=1 2", "PATH=" + os.Getenv("PATH")})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if status != c.want {
+				t.Errorf("run(%q) = %d; want %d", c.script, status, c.want)
+			}
+		})
+	}
+}
+
+func TestRun_NotFound(t *testing.T) {
+	if _, err := run([]string{"./does-not-exist"}, nil); err == nil {
+		t.Errorf("run(./does-not-exist): want error")
+	}
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+func TestRunWithSignals(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "signals")
+	ready := filepath.Join(dir, "ready")
+	script := `trap 'echo INT >> "$OUT"' INT
+trap 'echo TERM >> "$OUT"; exit 0' TERM
+touch "$READY"
+while :; do sleep 0.01; done`
+
+	signals := make(chan os.Signal)
+	done := make(chan int)
+	go func() {
+		status, err := runWithSignals([]string{"sh", "-c", script}, []string{"OUT=" + out, "READY=" + ready, "PATH=" + os.Getenv("PATH")}, signals)
+		if err != nil {
+			t.Errorf("runWithSignals: %v", err)
+		}
+		done <- status
+	}()
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// SIGINT is sent to the command by the terminal, so herofig must not forward it a second time.
+	signals <- os.Interrupt
+	signals <- syscall.SIGTERM
+
+	if status := <-done; status != 0 {
+		t.Errorf("runWithSignals() = %d; want 0", status)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "TERM\n" {
+		t.Errorf("command received %q; want only TERM", got)
+	}
+}
+
+func TestWriteTempEnvFile(t *testing.T) {
+	cfg := herofig.Config{"KEY": "-----BEGIN KEY-----\nabc\n-----END KEY-----\n", "PLAIN": "value"}
+	name, err := writeTempEnvFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(name)
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("env file permissions = %o; want 600", perm)
+	}
+	got, err := herofig.LoadQuoted(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["KEY"] != cfg["KEY"] || got["PLAIN"] != cfg["PLAIN"] {
+		t.Errorf("LoadQuoted(%s) = %q; want %q", name, got, cfg)
+	}
+	if b, _ := os.ReadFile(name); strings.Count(string(b), "\n") != len(cfg) {
+		t.Errorf("env file has %d lines; want one per variable:\n%s", strings.Count(string(b), "\n"), b)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+func Sync(flags *flag.FlagSet) Runner {
+	prefer := flags.String("prefer", "", "Resolve conflicts by keeping the local or remote value, instead of asking.")
+	quoted := quotedFlag(flags)
+
+	return func(ctx *Context, args []string) {
+		if len(args) != 1 {
+			ctx.UsageFatal()
+		}
+		if *prefer != "" && *prefer != "local" && *prefer != "remote" {
+			console.Fatalln(herofig.Errorf(herofig.ErrValidation, "invalid value %q for --prefer (must be local or remote)", *prefer))
+		}
+		file := args[0]
+		h := ctx.Backend()
+		env := ctx.Environment()
+
+		doc, local, err := loadSyncFile(file, *quoted)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		local = env.Filter(local)
+		remote, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting config from application: %v", err)
+		}
+		remote = env.Filter(remote)
+		base, err := herofig.LoadSyncBase(file, h.App())
+		if err != nil {
+			console.Fatalln(err)
+		}
+
+		result := herofig.ThreeWayMerge(base, local, remote)
+		if len(result.Conflicts) > 0 {
+			toLocal, toRemote, err := resolveConflicts(result.Conflicts, file, h.App(), *prefer)
+			if err != nil {
+				console.Fatalln(err)
+			}
+			result.Local = append(result.Local, toLocal...)
+			result.Remote = append(result.Remote, toRemote...)
+		}
+
+		// herofig cannot unset variables, so variables removed from the file remain on the application.
+		set := make(herofig.Config)
+		var pushed []herofig.Change
+		out := newSyncOutput(h.App(), file)
+		for _, c := range result.Remote {
+			if c.Kind == herofig.Removed {
+				console.Warnf("%s was removed from %s, but cannot be unset on %s", c.Key, file, h.App())
+				out.NotUnset = append(out.NotUnset, c.Key)
+				continue
+			}
+			set[c.Key] = c.New
+			pushed = append(pushed, c)
+		}
+
+		// The file is checked before anything is pushed, so that the sync is not left half done.
+		updated := doc.Apply(result.Local, *quoted)
+		if err := updated.CheckVerbatim(); err != nil {
+			console.Fatalf("pulling into %s: %v", file, err)
+		}
+
+		if len(set) > 0 {
+			checkProtection(h, env)
+			if err := h.SetConfig(ctx, set); err != nil {
+				console.Fatalf("pushing config: %v", err)
+			}
+		}
+		if len(result.Local) > 0 {
+			if err := os.WriteFile(file, updated.Bytes(), 0600); err != nil {
+				console.Fatalf("saving %s: %v", file, err)
+			}
+		}
+		if err := herofig.SaveSyncBase(file, h.App(), herofig.NewSyncBase(remote.Apply(pushed))); err != nil {
+			console.Fatalf("saving sync state: %v", err)
+		}
+
+		for _, c := range pushed {
+			out.Pushed = append(out.Pushed, c.Key)
+		}
+		for _, c := range result.Local {
+			out.Pulled = append(out.Pulled, c.Key)
+		}
+		if ctx.JSON() {
+			printJSON(out)
+			return
+		}
+		if len(out.Pushed) == 0 && len(out.Pulled) == 0 {
+			fmt.Println(console.Success("%s and %s are in sync", console.FilePath(file), console.App(h.App())))
+			return
+		}
+		if len(out.Pushed) > 0 {
+			fmt.Printf("Pushed %s to %s\n", keyList(out.Pushed), console.App(h.App()))
+		}
+		if len(out.Pulled) > 0 {
+			fmt.Printf("Pulled %s into %s\n", keyList(out.Pulled), console.FilePath(file))
+		}
+	}
+}
+
+// loadSyncFile returns the document of the env file to sync, and its config including any variables from included
+// files. A file that does not exist is empty. Values are decoded if quoted is true, and used verbatim otherwise.
+func loadSyncFile(file string, quoted bool) (herofig.Document, herofig.Config, error) {
+	parse, load := herofig.ParseDocument, herofig.Load
+	if quoted {
+		parse, load = herofig.ParseQuotedDocument, herofig.LoadQuoted
+	}
+
+	src, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, make(herofig.Config), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	cfg, err := load(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, cfg, nil
+}
+
+// resolveConflicts returns the changes that resolve conflicts, keeping the local or remote value as preferred, or
+// as chosen by the user if there is no preference.
+func resolveConflicts(conflicts []herofig.Conflict, file, app, prefer string) (toLocal, toRemote []herofig.Change, err error) {
+	if prefer == "" && (console.Prompts != console.PromptAuto || !console.IsTerminal(os.Stdin)) {
+		keys := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			keys[i] = c.Key
+		}
+		return nil, nil, herofig.Errorf(herofig.ErrConflict, "%d %s changed both in %s and on %s: %s. Pass --prefer local or --prefer remote to resolve the conflicts", len(keys), pluralize("variable", "", "s", len(keys)), file, app, strings.Join(keys, ", "))
+	}
+
+	for _, c := range conflicts {
+		keepLocal := prefer == "local"
+		if prefer == "" {
+			msg := fmt.Sprintf("%s was changed both in %s (%s) and on %s (%s).", c.Key, file, describeValue(c.KeepLocal), app, describeValue(c.KeepRemote))
+			if keepLocal, err = console.Confirm(msg, "Keep the local value?", false); err != nil {
+				return nil, nil, err
+			}
+		}
+		if keepLocal {
+			toRemote = append(toRemote, c.KeepLocal)
+		} else {
+			toLocal = append(toLocal, c.KeepRemote)
+		}
+	}
+	return toLocal, toRemote, nil
+}
+
+// describeValue describes the value that c changes a variable to, without revealing it.
+func describeValue(c herofig.Change) string {
+	if c.Kind == herofig.Removed {
+		return "removed"
+	}
+	return "set to " + herofig.Mask(c.New)
+}
+
+func keyList(keys []string) string {
+	colored := make([]string, len(keys))
+	for i, k := range keys {
+		colored[i] = console.ConfigKey(k)
+	}
+	return strings.Join(colored, ", ")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+func TestLoadSyncFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.env"), []byte("SHARED=base\nQUOTED=\"a b\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(file, []byte("#include base.env\nA=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		file   string
+		quoted bool
+		want   herofig.Config
+		lines  int
+	}{
+		{"includes", file, false, herofig.Config{"SHARED": "base", "QUOTED": `"a b"`, "A": "1"}, 2},
+		{"quoted", file, true, herofig.Config{"SHARED": "base", "QUOTED": "a b", "A": "1"}, 2},
+		{"missing file", filepath.Join(dir, "missing.env"), false, herofig.Config{}, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, cfg, err := loadSyncFile(c.file, c.quoted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, c.want) {
+				t.Errorf("loadSyncFile() config = %v; want %v", cfg, c.want)
+			}
+			// Only the file itself is rewritten, not the files it includes.
+			if len(doc) != c.lines {
+				t.Errorf("loadSyncFile() document = %q; want %d lines", doc.Lines(), c.lines)
+			}
+		})
+	}
+}
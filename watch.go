@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+	"github.com/kayex/herofig/internal/console"
+)
+
+func Watch(flags *flag.FlagSet) Runner {
+	interval := flags.Duration("interval", time.Second, "How often to check for changes.")
+	debounce := flags.Duration("debounce", 500*time.Millisecond, "How long a file must stay unchanged before it is synced.")
+
+	return func(ctx *Context, args []string) {
+		if len(args) != 1 {
+			ctx.UsageFatal()
+		}
+		file := args[0]
+		h := ctx.Backend()
+		env := ctx.Environment()
+		if err := checkWatchable(h, env); err != nil {
+			console.Fatalln(err)
+		}
+
+		remote, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting config: %v", err)
+		}
+		s := &fileSync{backend: h, env: env, file: file, synced: env.Filter(remote)}
+		if _, err := os.Stat(file); err != nil {
+			console.Fatalln(err)
+		}
+
+		console.Printf("Watching %s for changes to sync to %s. Press Ctrl+C to stop.\n", console.FilePath(file), console.App(h.App()))
+		s.sync(ctx)
+		poll(ctx, *interval, *debounce, func() string { return fileFingerprint(file) }, func() { s.sync(ctx) })
+		console.Println("Stopped watching")
+	}
+}
+
+func WatchRemote(flags *flag.FlagSet) Runner {
+	interval := flags.Duration("interval", 30*time.Second, "How often to check for changes.")
+	command := flags.String("exec", "", "A shell command to run when the config changes.")
+	write := flags.String("write", "", "A local file to rewrite with the config when it changes.")
+
+	return func(ctx *Context, args []string) {
+		if len(args) != 0 {
+			ctx.UsageFatal()
+		}
+		h := ctx.Backend()
+		env := ctx.Environment()
+
+		cfg, err := h.Config(ctx)
+		if err != nil {
+			console.Fatalf("getting config: %v", err)
+		}
+		w := &remoteWatch{backend: h, env: env, command: *command, write: *write, config: env.Filter(cfg), latest: env.Filter(cfg)}
+		if w.write != "" {
+			w.save()
+		}
+
+		console.Printf("Watching %s for changes every %s. Press Ctrl+C to stop.\n", console.App(h.App()), *interval)
+		poll(ctx, *interval, 0, func() string { return w.fingerprint(ctx) }, func() { w.changed(ctx.JSON()) })
+		console.Println("Stopped watching")
+	}
+}
+
+// checkWatchable returns an error unless the application may be synced without confirmation. Protected applications
+// are refused, whether they are selected using an environment or by name, and so is any application if the project
+// file cannot be read, since it may protect the application.
+func checkWatchable(h herofig.Backend, env herofig.Environment) error {
+	p, err := protection(h, env)
+	if err != nil {
+		return err
+	}
+	if p != herofig.Unprotected {
+		return herofig.Errorf(herofig.ErrPermission, "%s is protected, and cannot be watched", h.App())
+	}
+	return nil
+}
+
+// fileSync pushes the changes of a local file to an application.
+type fileSync struct {
+	backend herofig.Backend
+	env     herofig.Environment
+	file    string
+	// synced is the config of the application, as of the last sync.
+	synced herofig.Config
+	// local is the config of the file, as of the last sync.
+	local herofig.Config
+}
+
+// sync pushes the variables of the file that differ from the application. Errors are printed rather than fatal, so
+// that the file can be fixed while it is being watched.
+func (s *fileSync) sync(ctx context.Context) {
+	local, err := herofig.Load(s.file)
+	if err != nil {
+		console.Warnf("%s Not syncing %s: %v", timestamp(), s.file, err)
+		return
+	}
+	local = s.env.Filter(local)
+
+	changed := local.Filter(func(key string) bool {
+		v, ok := s.synced[key]
+		return !ok || v != local[key]
+	})
+	for k := range s.local {
+		if _, ok := local[k]; !ok {
+			console.Warnf("%s %s was removed from %s, but is still set on %s", timestamp(), k, s.file, s.backend.App())
+		}
+	}
+	s.local = local
+	if len(changed) == 0 {
+		return
+	}
+
+	var keys []string
+	for _, v := range changed.Ordered() {
+		keys = append(keys, console.ConfigKey(v.Key))
+	}
+	if err := s.backend.SetConfig(ctx, changed); err != nil {
+		if ctx.Err() == nil {
+			console.Warnf("%s Syncing %s failed: %v", timestamp(), strings.Join(keys, ", "), err)
+		}
+		return
+	}
+	s.synced = herofig.Merge(s.synced, changed)
+	console.Printf("%s Synced %s to %s\n", timestamp(), strings.Join(keys, ", "), console.App(s.backend.App()))
+}
+
+// remoteWatch reports changes to the config of an application.
+type remoteWatch struct {
+	backend herofig.Backend
+	env     herofig.Environment
+	// command is a shell command to run when the config changes.
+	command string
+	// write is a file to rewrite with the config when it changes.
+	write string
+	// config is the config as of the last reported change, and latest is the config as of the last poll.
+	config herofig.Config
+	latest herofig.Config
+}
+
+// fingerprint fetches the config of the application and returns its hash. If the config cannot be fetched, the
+// error is printed and the hash of the last fetched config is returned, so that errors are not reported as changes.
+func (w *remoteWatch) fingerprint(ctx context.Context) string {
+	cfg, err := w.backend.Config(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			console.Warnf("%s Getting config failed: %v", timestamp(), err)
+		}
+	} else {
+		w.latest = w.env.Filter(cfg)
+	}
+	return string(w.latest.Hash())
+}
+
+func (w *remoteWatch) changed(json bool) {
+	changes := herofig.Diff(w.config, w.latest)
+	w.config = w.latest
+	if len(changes) == 0 {
+		return
+	}
+
+	if json {
+		printJSON(newWatchOutput(w.backend.App(), time.Now(), changes))
+	} else {
+		fmt.Printf("%s %s changed:\n", timestamp(), console.App(w.backend.App()))
+		printDiff(maskedDiff(changes))
+	}
+
+	if w.write != "" {
+		w.save()
+	}
+	if w.command != "" {
+		w.exec(changes)
+	}
+}
+
+// save rewrites the file with the config, printing any error.
+func (w *remoteWatch) save() {
+	f := herofig.DetectFormat(w.write)
+	if err := herofig.SaveFormat(w.write, w.config, f, herofig.FormatOptions{Separator: "_", Name: w.backend.App()}); err != nil {
+		console.Warnf("%s Saving config to %s failed: %v", timestamp(), w.write, err)
+	}
+}
+
+// exec runs the command with the changed keys in HEROFIG_CHANGED_KEYS, printing any error.
+func (w *remoteWatch) exec(changes []herofig.Change) {
+	keys := make([]string, len(changes))
+	for i, c := range changes {
+		keys[i] = c.Key
+	}
+	shell := []string{"sh", "-c", w.command}
+	if runtime.GOOS == "windows" {
+		shell = []string{"cmd", "/C", w.command}
+	}
+	environ := append(os.Environ(), "HEROFIG_APP="+w.backend.App(), "HEROFIG_CHANGED_KEYS="+strings.Join(keys, ","))
+
+	status, err := run(shell, environ)
+	if err != nil {
+		console.Warnf("%s Running %s failed: %v", timestamp(), w.command, err)
+	} else if status != 0 {
+		console.Warnf("%s %s exited with status %d", timestamp(), w.command, status)
+	}
+}
+
+// maskedDiff returns changes as diff lines, with the values masked.
+func maskedDiff(changes []herofig.Change) []string {
+	var lines []string
+	for _, c := range changes {
+		if c.Kind != herofig.Added {
+			lines = append(lines, fmt.Sprintf("-%s=%s", c.Key, herofig.Mask(c.Old)))
+		}
+		if c.Kind != herofig.Removed {
+			lines = append(lines, fmt.Sprintf("+%s=%s", c.Key, herofig.Mask(c.New)))
+		}
+	}
+	return lines
+}
+
+// poll calls fingerprint every interval until ctx is done, and calls onChange when its result has changed and then
+// stayed the same for debounce.
+func poll(ctx context.Context, interval, debounce time.Duration, fingerprint func() string, onChange func()) {
+	reported := fingerprint()
+	current, stableSince := reported, time.Now()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if f := fingerprint(); f != current {
+				current, stableSince = f, now
+			}
+			if current != reported && now.Sub(stableSince) >= debounce {
+				reported = current
+				onChange()
+			}
+		}
+	}
+}
+
+// fileFingerprint returns a hash of the contents of file, or the error reading it.
+func fileFingerprint(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err.Error()
+	}
+	sum := sha256.Sum256(b)
+	return string(sum[:])
+}
+
+func timestamp() string {
+	return time.Now().Format(time.TimeOnly)
+}
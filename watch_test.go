@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kayex/herofig/herofig"
+)
+
+func TestFileSync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "dev.env")
+	b, err := herofig.NewFileBackend(filepath.Join(dir, "app.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetConfig(ctx, herofig.Config{"A": "1", "HEROKU_APP": "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fileSync{
+		backend: b,
+		env:     herofig.Environment{Ignore: []string{"IGNORED"}},
+		file:    file,
+		synced:  herofig.Config{"A": "1", "HEROKU_APP": "x"},
+	}
+	steps := []struct {
+		content string
+		want    herofig.Config
+	}{
+		{"A=1\nB=2\nIGNORED=1\n", herofig.Config{"A": "1", "B": "2", "HEROKU_APP": "x"}},
+		{"A=10\nB=2\n", herofig.Config{"A": "10", "B": "2", "HEROKU_APP": "x"}},
+		// Parse errors and removed keys leave the application unchanged.
+		{"A=10\nINVALID\n", herofig.Config{"A": "10", "B": "2", "HEROKU_APP": "x"}},
+		{"A=10\n", herofig.Config{"A": "10", "B": "2", "HEROKU_APP": "x"}},
+	}
+
+	for _, step := range steps {
+		if err := os.WriteFile(file, []byte(step.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		s.sync(ctx)
+		got, err := b.Config(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("after syncing %q, config = %v; want %v", step.content, got, step.want)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := []string{"a", "a", "b", "b", "c"}
+	i := 0
+	fingerprint := func() string {
+		v := values[min(i, len(values)-1)]
+		i++
+		return v
+	}
+
+	var changes int
+	poll(ctx, time.Millisecond, 0, fingerprint, func() {
+		changes++
+		if changes == 2 {
+			cancel()
+		}
+	})
+	if changes != 2 {
+		t.Errorf("poll reported %d changes; want 2", changes)
+	}
+}
+
+func TestMaskedDiff(t *testing.T) {
+	changes := []herofig.Change{
+		{Kind: herofig.Added, Key: "A", New: "1"},
+		{Kind: herofig.Changed, Key: "B", Old: "supersecretvalue", New: "anothersecretvalue"},
+		{Kind: herofig.Removed, Key: "C", Old: "3"},
+	}
+	want := []string{"+A=****", "-B=****alue", "+B=****alue", "-C=****"}
+	if got := maskedDiff(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("maskedDiff() = %q; want %q", got, want)
+	}
+}
+
+func TestCheckWatchable(t *testing.T) {
+	cases := []struct {
+		name    string
+		project string
+		env     herofig.Environment
+		wantErr bool
+	}{
+		{"no project", "", herofig.Environment{}, false},
+		{"unprotected", `{"environments": {"dev": {"app": "file:other.json"}}}`, herofig.Environment{}, false},
+		{"protected environment", "", herofig.Environment{Protection: herofig.ConfirmWrites}, true},
+		{"protected app", `{"environments": {"prod": {"app": "file:app.json", "protection": "readonly"}}}`, herofig.Environment{}, true},
+		{"invalid project", `{"environments": `, herofig.Environment{}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			if c.project != "" {
+				if err := os.WriteFile(herofig.ProjectFilename, []byte(c.project), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b, err := herofig.NewFileBackend("app.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = checkWatchable(b, c.env)
+			if (err != nil) != c.wantErr {
+				t.Errorf("checkWatchable() error = %v; want error %v", err, c.wantErr)
+			}
+		})
+	}
+}